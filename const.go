@@ -9,3 +9,4 @@ import (
 
 var byteType = reflect.TypeOf(byte(0))
 var typeWrapperType = reflect.TypeOf((*TypeWrapper[struct{}, interface{}])(nil)).Elem()
+var unionNoneType = reflect.TypeOf(UnionNone{})
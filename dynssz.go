@@ -8,9 +8,12 @@ package dynssz
 
 import (
 	"fmt"
+	"io"
 	"reflect"
+	"sync"
 
 	"github.com/pk910/dynamic-ssz/hasher"
+	"github.com/pk910/dynamic-ssz/sszutils"
 )
 
 // DynSsz is a dynamic SSZ encoder/decoder that uses runtime reflection to handle dynamic field sizes.
@@ -47,6 +50,7 @@ type DynSsz struct {
 	typeCache      *TypeCache                  // Cache for type descriptors
 	specValues     map[string]any              // Dynamic specification values
 	specValueCache map[string]*cachedSpecValue // Cache for parsed specification expressions
+	specValueMutex sync.RWMutex                // Mutex for the specification expression cache
 
 	// NoFastSsz disables the use of fastssz for static types.
 	// When true, all encoding/decoding uses reflection-based processing.
@@ -60,6 +64,31 @@ type DynSsz struct {
 	// Verbose enables detailed logging of encoding/decoding operations.
 	// Useful for debugging but impacts performance.
 	Verbose bool
+
+	// Logger receives the detailed trace output of encoding/decoding and hashing operations.
+	// When set, it is used instead of the Verbose stdout output. nil (default) disables logging unless
+	// Verbose is enabled. Loggers implementing Enabled() bool can turn the tracing off at runtime.
+	Logger Logger
+
+	// StrictSpecValues makes dynamic size expressions that reference unknown spec values fail with an error.
+	// When false (default), unresolvable expressions silently fall back to the static ssz-size/ssz-max/ssz-bitsize
+	// defaults. Expressions without a static fallback always fail.
+	// Must be set before the first operation, as type descriptors are cached.
+	StrictSpecValues bool
+
+	// MaxConcurrency sets the maximum number of goroutines used to hash the elements of large
+	// lists and vectors of containers in parallel (e.g. the validator registry).
+	// 0 or 1 keeps the default serial behavior. Parallel hashing only applies to slices above
+	// an internal size threshold and is disabled while Verbose logging or a Logger is enabled.
+	MaxConcurrency int
+
+	// RootCache enables incremental hashing by reusing the cached roots of unchanged pointer values.
+	// nil (default) disables caching. Modified values must be marked dirty, see RootCache for details.
+	RootCache *RootCache
+
+	// StrictLimits makes marshalling, size calculation and hashing fail with an error when a list exceeds its ssz-max limit.
+	// When false (default), oversized lists are encoded and merkleized as-is for backward compatibility.
+	StrictLimits bool
 }
 
 // NewDynSsz creates a new instance of the DynSsz encoder/decoder.
@@ -110,6 +139,52 @@ func NewDynSsz(specs map[string]any) *DynSsz {
 	return dynssz
 }
 
+// NewDynSszWithPreset creates a new DynSsz instance with the spec constants of a well-known preset.
+//
+// Supported presets are PresetMainnet ("mainnet") and PresetMinimal ("minimal"), which contain the
+// consensus preset constants from phase0 up to electra. An empty preset name starts with an empty
+// constant table. The constants map is applied on top of the preset and can be used to add
+// further constants (e.g. config values) or to override preset values.
+//
+// Expressions referencing constants missing from the table fall back to the static ssz-* tag
+// values unless StrictSpecValues is set; without a static fallback they always fail.
+//
+// Parameters:
+//   - preset: The name of the preset to load, or an empty string for no preset
+//   - constants: Additional spec constants, can be nil
+//
+// Returns:
+//   - *DynSsz: A new DynSsz instance using the combined spec constants
+//   - error: An error if the preset is unknown
+//
+// Example:
+//
+//	ds, err := dynssz.NewDynSszWithPreset(dynssz.PresetMinimal, map[string]uint64{
+//	    "MAX_BLOBS_PER_BLOCK_ELECTRA": 9,
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+func NewDynSszWithPreset(preset string, constants map[string]uint64) (*DynSsz, error) {
+	specs := map[string]any{}
+
+	if preset != "" {
+		presetValues, ok := presets[preset]
+		if !ok {
+			return nil, fmt.Errorf("unknown spec preset: %v", preset)
+		}
+		for name, value := range presetValues {
+			specs[name] = value
+		}
+	}
+
+	for name, value := range constants {
+		specs[name] = value
+	}
+
+	return NewDynSsz(specs), nil
+}
+
 // GetTypeCache returns the type cache for the DynSsz instance.
 //
 // The type cache stores computed type descriptors for types used in encoding/decoding operations.
@@ -182,13 +257,13 @@ func (d *DynSsz) MarshalSSZ(source any) ([]byte, error) {
 
 	size, err := d.getSszValueSize(sourceTypeDesc, sourceValue)
 	if err != nil {
-		return nil, err
+		return nil, wrapRootError(err, sourceType)
 	}
 
 	buf := make([]byte, 0, size)
 	newBuf, err := d.marshalType(sourceTypeDesc, sourceValue, buf, 0)
 	if err != nil {
-		return nil, err
+		return nil, wrapRootError(err, sourceType)
 	}
 
 	if uint32(len(newBuf)) != size {
@@ -236,12 +311,75 @@ func (d *DynSsz) MarshalSSZTo(source any, buf []byte) ([]byte, error) {
 
 	newBuf, err := d.marshalType(sourceTypeDesc, sourceValue, buf, 0)
 	if err != nil {
-		return nil, err
+		return nil, wrapRootError(err, sourceType)
 	}
 
 	return newBuf, nil
 }
 
+// MarshalSSZToWriter serializes the given source into its SSZ (Simple Serialize) representation and writes
+// it to the provided writer.
+//
+// Unlike MarshalSSZ, the encoding is not built in memory as a whole. Containers and lists are written
+// incrementally, while the offsets of variable-size fields are computed upfront via a size calculation
+// pass, so the output is byte-identical to MarshalSSZ. Subtrees that are encoded by fastssz or custom
+// marshalers are buffered before they are written.
+//
+// Errors returned by the writer abort the serialization immediately. In that case, the returned byte
+// count reports how much data has already been written, so callers can discard the partial output.
+//
+// Parameters:
+//   - source: Any Go value to be serialized. Must be a type supported by SSZ encoding.
+//   - w: The writer that receives the serialized data
+//
+// Returns:
+//   - int: The number of bytes written to w
+//   - error: An error if serialization or writing fails
+//
+// Example:
+//
+//	file, err := os.Create("state.ssz")
+//	if err != nil {
+//	    log.Fatal("Failed to create file:", err)
+//	}
+//	defer file.Close()
+//
+//	written, err := ds.MarshalSSZToWriter(state, file)
+//	if err != nil {
+//	    log.Fatal("Failed to write state:", err)
+//	}
+//	fmt.Printf("Wrote %d bytes\n", written)
+func (d *DynSsz) MarshalSSZToWriter(source any, w io.Writer) (int, error) {
+	sourceType := reflect.TypeOf(source)
+	sourceValue := reflect.ValueOf(source)
+
+	sourceTypeDesc, err := d.typeCache.GetTypeDescriptor(sourceType, nil, nil, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	// the size pass validates all limits before any data is written
+	size, err := d.getSszValueSize(sourceTypeDesc, sourceValue)
+	if err != nil {
+		return 0, wrapRootError(err, sourceType)
+	}
+
+	sw := newStreamWriter(w)
+	err = d.marshalTypeToStream(sourceTypeDesc, sourceValue, sw, 0)
+	if err == nil {
+		err = sw.flush()
+	}
+	if err != nil {
+		return sw.written, wrapRootError(err, sourceType)
+	}
+
+	if uint32(sw.written) != size {
+		return sw.written, fmt.Errorf("ssz length does not match expected length (expected: %v, got: %v)", size, sw.written)
+	}
+
+	return sw.written, nil
+}
+
 // SizeSSZ calculates the size of the given source object when serialized using SSZ encoding.
 //
 // This method is useful for pre-allocating buffers with the exact size needed for serialization,
@@ -285,7 +423,7 @@ func (d *DynSsz) SizeSSZ(source any) (int, error) {
 
 	size, err := d.getSszValueSize(sourceTypeDesc, sourceValue)
 	if err != nil {
-		return 0, err
+		return 0, wrapRootError(err, sourceType)
 	}
 
 	return int(size), nil
@@ -341,7 +479,7 @@ func (d *DynSsz) UnmarshalSSZ(target any, ssz []byte) error {
 
 	consumedBytes, err := d.unmarshalType(targetTypeDesc, targetValue, ssz, 0)
 	if err != nil {
-		return err
+		return wrapRootError(err, targetType)
 	}
 
 	if consumedBytes != len(ssz) {
@@ -390,21 +528,45 @@ func (d *DynSsz) UnmarshalSSZ(target any, ssz []byte) error {
 //	}
 //	fmt.Printf("Block root: %x\n", root)
 func (d *DynSsz) HashTreeRoot(source any) ([32]byte, error) {
+	var root [32]byte
+	if err := d.HashTreeRootInto(source, &root); err != nil {
+		return [32]byte{}, err
+	}
+
+	return root, nil
+}
+
+// HashTreeRootInto computes the hash tree root of the given source object and writes it into out.
+//
+// This is the allocation free variant of HashTreeRoot for tight loops: the root is copied from the
+// pooled hasher into the caller's array. The result is identical to HashTreeRoot.
+//
+// Parameters:
+//   - source: Any Go value for which to compute the hash tree root
+//   - out: The array that receives the root, it is left untouched if hashing fails
+//
+// Returns:
+//   - error: An error if the computation fails due to unsupported types or hashing errors
+//
+// Example:
+//
+//	var root [32]byte
+//	for _, validator := range validators {
+//	    if err := ds.HashTreeRootInto(validator, &root); err != nil {
+//	        log.Fatal("Failed to compute root:", err)
+//	    }
+//	    // use root...
+//	}
+func (d *DynSsz) HashTreeRootInto(source any, out *[32]byte) error {
 	sourceType := reflect.TypeOf(source)
 	sourceValue := reflect.ValueOf(source)
 
 	sourceTypeDesc, err := d.typeCache.GetTypeDescriptor(sourceType, nil, nil, nil)
 	if err != nil {
-		return [32]byte{}, err
-	}
-
-	var pool *hasher.HasherPool
-	if d.NoFastHash {
-		pool = &hasher.DefaultHasherPool
-	} else {
-		pool = &hasher.FastHasherPool
+		return err
 	}
 
+	pool := d.getHasherPool()
 	hh := pool.Get()
 	defer func() {
 		pool.Put(hh)
@@ -412,10 +574,84 @@ func (d *DynSsz) HashTreeRoot(source any) ([32]byte, error) {
 
 	err = d.buildRootFromType(sourceTypeDesc, sourceValue, hh, false, 0)
 	if err != nil {
-		return [32]byte{}, err
+		return wrapRootError(err, sourceType)
+	}
+
+	if hh.Index() != 32 {
+		return fmt.Errorf("expected 32 byte size")
 	}
 
-	return hh.HashRoot()
+	copy(out[:], hh.Hash())
+
+	return nil
+}
+
+// HashTreeRootWith computes the hash tree root of the given source object and appends it to the provided hasher.
+//
+// This method allows dynamic ssz types to be embedded into types with generated fastssz code. A parent type that
+// implements HashTreeRootWith can delegate the hashing of a single field with dynamic layout to dynssz, while
+// merkleizing its own chunks as usual. The source always contributes exactly one 32 byte chunk at the current
+// index of the hasher, so the surrounding Merkleize call of the parent produces the correct combined root.
+//
+// If hh is a *hasher.Hasher (the hasher used by dynssz itself), the source is hashed directly into it.
+// Other HashWalker implementations (e.g. the fastssz hasher) receive the root computed with a pooled hasher.
+//
+// Parameters:
+//   - source: Any Go value for which to compute the hash tree root
+//   - hh: The hasher of the parent value
+//
+// Returns:
+//   - error: An error if the computation fails due to unsupported types or hashing errors
+//
+// Example:
+//
+//	func (b *BeaconBlockBody) HashTreeRootWith(hh ssz.HashWalker) error {
+//	    indx := hh.Index()
+//	    hh.PutBytes(b.RandaoReveal[:])
+//	    // ... other fields
+//	    if err := ds.HashTreeRootWith(b.ExecutionPayload, hh); err != nil {
+//	        return err
+//	    }
+//	    hh.Merkleize(indx)
+//	    return nil
+//	}
+func (d *DynSsz) HashTreeRootWith(source any, hh sszutils.HashWalker) error {
+	sourceType := reflect.TypeOf(source)
+	sourceValue := reflect.ValueOf(source)
+
+	sourceTypeDesc, err := d.typeCache.GetTypeDescriptor(sourceType, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	if parentHasher, ok := hh.(*hasher.Hasher); ok {
+		err = d.buildRootFromType(sourceTypeDesc, sourceValue, parentHasher, false, 0)
+		if err != nil {
+			return wrapRootError(err, sourceType)
+		}
+
+		return nil
+	}
+
+	pool := d.getHasherPool()
+	rootHasher := pool.Get()
+	defer func() {
+		pool.Put(rootHasher)
+	}()
+
+	err = d.buildRootFromType(sourceTypeDesc, sourceValue, rootHasher, false, 0)
+	if err != nil {
+		return wrapRootError(err, sourceType)
+	}
+
+	root, err := rootHasher.HashRoot()
+	if err != nil {
+		return err
+	}
+
+	hh.AppendBytes32(root[:])
+
+	return nil
 }
 
 // ValidateType validates whether a given type is compatible with SSZ encoding/decoding.
@@ -0,0 +1,816 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+	"github.com/pk910/dynamic-ssz/sszutils"
+)
+
+type concurrencyTestElement struct {
+	Index uint64
+	Data  []byte `ssz-max:"64" dynssz-max:"MAX_DATA"`
+}
+
+type concurrencyTestPayload struct {
+	Slot     uint64
+	Roots    [][32]byte                `ssz-size:"4" dynssz-size:"ROOTS_SIZE"`
+	Elements []*concurrencyTestElement `ssz-max:"1024"`
+	Bits     []byte                    `ssz-type:"bitlist" ssz-max:"64"`
+	Optional *uint32                   `ssz-type:"optional"`
+}
+
+// TestConcurrentOperations runs all read-only operations in parallel on a shared instance.
+// It is most effective when run with the race detector (go test -race).
+func TestConcurrentOperations(t *testing.T) {
+	specs := map[string]any{
+		"MAX_DATA":   uint64(32),
+		"ROOTS_SIZE": uint64(8),
+	}
+
+	optional := uint32(7)
+	payloads := []any{
+		&concurrencyTestPayload{
+			Slot:  1,
+			Roots: make([][32]byte, 8),
+			Elements: []*concurrencyTestElement{
+				{Index: 1, Data: []byte{1, 2, 3}},
+				{Index: 2},
+			},
+			Bits:     []byte{0x0f},
+			Optional: &optional,
+		},
+		&concurrencyTestElement{Index: 3, Data: bytes.Repeat([]byte{3}, 32)},
+		&struct {
+			A uint16
+			B []concurrencyTestElement `ssz-max:"300"`
+		}{A: 5, B: make([]concurrencyTestElement, 300)},
+	}
+
+	// calculate the expected results with a separate instance
+	expectedSsz := make([][]byte, len(payloads))
+	expectedRoots := make([][32]byte, len(payloads))
+	for i, payload := range payloads {
+		ds := NewDynSsz(specs)
+
+		var err error
+		expectedSsz[i], err = ds.MarshalSSZ(payload)
+		if err != nil {
+			t.Fatalf("payload %d: marshal failed: %v", i, err)
+		}
+
+		expectedRoots[i], err = ds.HashTreeRoot(payload)
+		if err != nil {
+			t.Fatalf("payload %d: hashing failed: %v", i, err)
+		}
+	}
+
+	// the shared instance starts with empty caches, so the type cache and spec value
+	// cache are populated concurrently
+	ds := NewDynSsz(specs)
+	ds.MaxConcurrency = 4
+
+	var wg sync.WaitGroup
+	workerErrs := make(chan error, 64)
+	for w := 0; w < 64; w++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+
+			for i := 0; i < 20; i++ {
+				index := (worker + i) % len(payloads)
+				if err := checkConcurrentOperations(ds, payloads[index], expectedSsz[index], expectedRoots[index]); err != nil {
+					workerErrs <- fmt.Errorf("payload %d: %w", index, err)
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(workerErrs)
+
+	for err := range workerErrs {
+		t.Error(err)
+	}
+}
+
+func checkConcurrentOperations(ds *DynSsz, payload any, expectedSsz []byte, expectedRoot [32]byte) error {
+	size, err := ds.SizeSSZ(payload)
+	if err != nil {
+		return fmt.Errorf("size failed: %w", err)
+	}
+	if size != len(expectedSsz) {
+		return fmt.Errorf("size mismatch: got %d, wanted %d", size, len(expectedSsz))
+	}
+
+	data, err := ds.MarshalSSZ(payload)
+	if err != nil {
+		return fmt.Errorf("marshal failed: %w", err)
+	}
+	if !bytes.Equal(data, expectedSsz) {
+		return fmt.Errorf("marshal mismatch")
+	}
+
+	decoded := reflect.New(reflect.TypeOf(payload).Elem()).Interface()
+	if err := ds.UnmarshalSSZ(decoded, data); err != nil {
+		return fmt.Errorf("unmarshal failed: %w", err)
+	}
+
+	root, err := ds.HashTreeRoot(decoded)
+	if err != nil {
+		return fmt.Errorf("hashing failed: %w", err)
+	}
+	if root != expectedRoot {
+		return fmt.Errorf("root mismatch: got 0x%x, wanted 0x%x", root, expectedRoot)
+	}
+
+	return nil
+}
+
+func TestConcurrentGlobalDynSsz(t *testing.T) {
+	var wg sync.WaitGroup
+	instances := make([]*DynSsz, 16)
+	for i := range instances {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			instances[i] = GetGlobalDynSsz()
+		}(i)
+	}
+	wg.Wait()
+
+	for i, instance := range instances {
+		if instance != instances[0] {
+			t.Errorf("instance %d differs from the first global instance", i)
+		}
+	}
+}
+
+func TestNewDynSszWithPreset(t *testing.T) {
+	type Payload struct {
+		A []uint8  `ssz-size:"8" dynssz-size:"SLOTS_PER_EPOCH*32"`
+		B []uint16 `ssz-max:"8" dynssz-max:"(MAX_VALIDATORS_PER_COMMITTEE + SLOTS_PER_EPOCH) / 2 - 1"`
+		C []uint8  `ssz-size:"8" dynssz-size:"CUSTOM_SIZE"`
+	}
+
+	testCases := []struct {
+		preset        string
+		constants     map[string]uint64
+		expectedSize  uint32
+		expectedLimit uint64
+		expectedCSize uint32
+	}{
+		{PresetMainnet, nil, 1024, 1039, 8},
+		{PresetMinimal, nil, 256, 1027, 8},
+		{PresetMinimal, map[string]uint64{"SLOTS_PER_EPOCH": 4, "CUSTOM_SIZE": 16}, 128, 1025, 16},
+		{"", map[string]uint64{"SLOTS_PER_EPOCH": 1, "MAX_VALIDATORS_PER_COMMITTEE": 9}, 32, 4, 8},
+	}
+
+	for _, tc := range testCases {
+		ds, err := NewDynSszWithPreset(tc.preset, tc.constants)
+		if err != nil {
+			t.Fatalf("preset %v: unexpected error: %v", tc.preset, err)
+		}
+
+		desc, err := ds.GetTypeCache().GetTypeDescriptor(reflect.TypeOf(Payload{}), nil, nil, nil)
+		if err != nil {
+			t.Fatalf("preset %v: failed to get type descriptor: %v", tc.preset, err)
+		}
+
+		fields := desc.ContainerDesc.Fields
+		if fields[0].Type.Size != tc.expectedSize {
+			t.Errorf("preset %v: expected size %v, got %v", tc.preset, tc.expectedSize, fields[0].Type.Size)
+		}
+		if fields[1].Type.Limit != tc.expectedLimit {
+			t.Errorf("preset %v: expected limit %v, got %v", tc.preset, tc.expectedLimit, fields[1].Type.Limit)
+		}
+		if fields[2].Type.Size != tc.expectedCSize {
+			t.Errorf("preset %v: expected size %v, got %v", tc.preset, tc.expectedCSize, fields[2].Type.Size)
+		}
+	}
+
+	_, err := NewDynSszWithPreset("goerli", nil)
+	if err == nil || !contains(err.Error(), "unknown spec preset: goerli") {
+		t.Errorf("expected unknown preset error, got %v", err)
+	}
+}
+
+func TestPresetValues(t *testing.T) {
+	testCases := []struct {
+		preset string
+		file   string
+		sample map[string]uint64
+	}{
+		{PresetMainnet, "spectests/presets/mainnet-preset.yaml", map[string]uint64{
+			"SLOTS_PER_EPOCH":                     32,
+			"SYNC_COMMITTEE_SIZE":                 512,
+			"MAX_BLOB_COMMITMENTS_PER_BLOCK":      4096,
+			"MAX_DEPOSIT_REQUESTS_PER_PAYLOAD":    8192,
+			"MAX_WITHDRAWAL_REQUESTS_PER_PAYLOAD": 16,
+		}},
+		{PresetMinimal, "spectests/presets/minimal-preset.yaml", map[string]uint64{
+			"SLOTS_PER_EPOCH":                     8,
+			"SYNC_COMMITTEE_SIZE":                 32,
+			"MAX_BLOB_COMMITMENTS_PER_BLOCK":      32,
+			"MAX_DEPOSIT_REQUESTS_PER_PAYLOAD":    4,
+			"MAX_WITHDRAWAL_REQUESTS_PER_PAYLOAD": 2,
+		}},
+	}
+
+	for _, tc := range testCases {
+		ds, err := NewDynSszWithPreset(tc.preset, nil)
+		if err != nil {
+			t.Fatalf("preset %v: unexpected error: %v", tc.preset, err)
+		}
+
+		checkValue := func(name string, expected uint64) {
+			t.Helper()
+			ok, value, err := ds.ResolveSpecValue(name)
+			if err != nil || !ok {
+				t.Errorf("preset %v: failed to resolve %v: %v", tc.preset, name, err)
+			} else if value != expected {
+				t.Errorf("preset %v: %v is %v, wanted %v", tc.preset, name, value, expected)
+			}
+		}
+
+		for name, expected := range tc.sample {
+			checkValue(name, expected)
+		}
+
+		// all values of the consensus-specs preset files must match
+		yamlData, err := os.ReadFile(tc.file)
+		if err != nil {
+			t.Fatalf("preset %v: failed to read preset file: %v", tc.preset, err)
+		}
+
+		for _, line := range strings.Split(string(yamlData), "\n") {
+			name, valueStr, found := strings.Cut(line, ":")
+			if !found || strings.HasPrefix(name, "#") {
+				continue
+			}
+
+			expected, err := strconv.ParseUint(strings.TrimSpace(valueStr), 10, 64)
+			if err != nil {
+				t.Fatalf("preset %v: invalid value for %v: %v", tc.preset, name, valueStr)
+			}
+			checkValue(name, expected)
+		}
+	}
+}
+
+func TestStrictSpecValues(t *testing.T) {
+	testCases := []struct {
+		name        string
+		payload     any
+		expectedErr string
+	}{
+		{
+			name: "unknown_size_value",
+			payload: struct {
+				A []uint8 `ssz-size:"8" dynssz-size:"SLOTS_PER_EPOCH*UNKNOWN_VALUE"`
+			}{},
+			expectedErr: "error parsing dynssz-size tag for 'A' field: unknown spec value UNKNOWN_VALUE in expression 'SLOTS_PER_EPOCH*UNKNOWN_VALUE'",
+		},
+		{
+			name: "unknown_max_value",
+			payload: struct {
+				A []uint8 `ssz-max:"8" dynssz-max:"UNKNOWN_MAX"`
+			}{},
+			expectedErr: "error parsing dynssz-max tag for 'A' field: unknown spec value UNKNOWN_MAX",
+		},
+		{
+			name: "unknown_bitsize_value",
+			payload: struct {
+				A [2]byte `ssz-bitsize:"12" dynssz-bitsize:"UNKNOWN_BITS"`
+			}{},
+			expectedErr: "error parsing dynssz-bitsize tag for 'A' field: unknown spec value UNKNOWN_BITS",
+		},
+	}
+
+	// expressions without a static fallback always fail, regardless of the strict mode
+	noFallbackCases := []struct {
+		name        string
+		payload     any
+		expectedErr string
+	}{
+		{
+			name: "size_without_fallback",
+			payload: struct {
+				A []uint8 `dynssz-size:"UNKNOWN_VALUE"`
+			}{},
+			expectedErr: "error parsing dynssz-size tag for 'A' field: unknown spec value UNKNOWN_VALUE in expression 'UNKNOWN_VALUE' (no ssz-size fallback)",
+		},
+		{
+			name: "inner_size_without_fallback",
+			payload: struct {
+				A [][]uint8 `ssz-size:"2" dynssz-size:"2,UNKNOWN_VALUE"`
+			}{},
+			expectedErr: "(no ssz-size fallback)",
+		},
+		{
+			name: "max_without_fallback",
+			payload: struct {
+				A []uint8 `dynssz-max:"UNKNOWN_MAX"`
+			}{},
+			expectedErr: "error parsing dynssz-max tag for 'A' field: unknown spec value UNKNOWN_MAX in expression 'UNKNOWN_MAX' (no ssz-max fallback)",
+		},
+		{
+			name: "bitsize_without_fallback",
+			payload: struct {
+				A [2]byte `ssz-size:"2" dynssz-bitsize:"UNKNOWN_BITS"`
+			}{},
+			expectedErr: "error parsing dynssz-bitsize tag for 'A' field: unknown spec value UNKNOWN_BITS in expression 'UNKNOWN_BITS' (no ssz-bitsize fallback)",
+		},
+	}
+
+	for _, tc := range noFallbackCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ds, err := NewDynSszWithPreset(PresetMainnet, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			_, err = ds.MarshalSSZ(tc.payload)
+			if err == nil {
+				t.Errorf("expected error containing '%s', but got no error", tc.expectedErr)
+			} else if !contains(err.Error(), tc.expectedErr) {
+				t.Errorf("expected error containing '%s', but got '%s'", tc.expectedErr, err.Error())
+			}
+		})
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ds, err := NewDynSszWithPreset(PresetMainnet, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			// without strict mode, the static defaults are used
+			if _, err := ds.MarshalSSZ(tc.payload); err != nil {
+				t.Errorf("unexpected error in non-strict mode: %v", err)
+			}
+
+			ds, _ = NewDynSszWithPreset(PresetMainnet, nil)
+			ds.StrictSpecValues = true
+
+			_, err = ds.MarshalSSZ(tc.payload)
+			if err == nil {
+				t.Errorf("expected error containing '%s', but got no error", tc.expectedErr)
+			} else if !contains(err.Error(), tc.expectedErr) {
+				t.Errorf("expected error containing '%s', but got '%s'", tc.expectedErr, err.Error())
+			}
+		})
+	}
+}
+
+type strictLimitsTestElement struct {
+	Data []byte `ssz-max:"4"`
+}
+
+type strictLimitsTestPayload struct {
+	Values   []uint64                   `ssz-max:"4"`
+	Elements []*strictLimitsTestElement `ssz-max:"2"`
+	Roots    [][32]byte                 `ssz-size:"2"`
+}
+
+func TestStrictLimits(t *testing.T) {
+	testCases := []struct {
+		name        string
+		payload     *strictLimitsTestPayload
+		expectedErr string
+	}{
+		{
+			name:        "basic_list",
+			payload:     &strictLimitsTestPayload{Values: make([]uint64, 5)},
+			expectedErr: "strictLimitsTestPayload.Values: list length is higher than max value (length: 5, max: 4)",
+		},
+		{
+			name:        "container_list",
+			payload:     &strictLimitsTestPayload{Elements: make([]*strictLimitsTestElement, 3)},
+			expectedErr: "strictLimitsTestPayload.Elements: list length is higher than max value (length: 3, max: 2)",
+		},
+		{
+			name: "nested_byte_list",
+			payload: &strictLimitsTestPayload{Elements: []*strictLimitsTestElement{
+				{},
+				{Data: []byte{1, 2, 3, 4, 5}},
+			}},
+			expectedErr: "strictLimitsTestPayload.Elements[1].Data: list length is higher than max value (length: 5, max: 4)",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// without strict mode, oversized lists are encoded and hashed as-is
+			ds := NewDynSsz(nil)
+			if _, err := ds.MarshalSSZ(tc.payload); err != nil {
+				t.Errorf("unexpected marshal error in non-strict mode: %v", err)
+			}
+			if _, err := ds.HashTreeRoot(tc.payload); err != nil && !contains(err.Error(), "list too big") {
+				t.Errorf("unexpected hash error in non-strict mode: %v", err)
+			}
+
+			ds = NewDynSsz(nil)
+			ds.StrictLimits = true
+
+			checkErr := func(op string, err error) {
+				t.Helper()
+				if err == nil {
+					t.Errorf("%v: expected error containing '%s', but got no error", op, tc.expectedErr)
+				} else if !contains(err.Error(), tc.expectedErr) {
+					t.Errorf("%v: expected error containing '%s', but got '%s'", op, tc.expectedErr, err.Error())
+				} else if !errors.Is(err, sszutils.ErrListTooBig) {
+					t.Errorf("%v: expected ErrListTooBig, got %v", op, err)
+				}
+			}
+
+			_, err := ds.MarshalSSZ(tc.payload)
+			checkErr("marshal", err)
+
+			_, err = ds.MarshalSSZTo(tc.payload, nil)
+			checkErr("marshal to", err)
+
+			_, err = ds.MarshalSSZToWriter(tc.payload, io.Discard)
+			checkErr("marshal to writer", err)
+
+			// limit violations are detected before any data is written
+			writer := &failingWriter{limit: 1 << 20}
+			written, err := ds.MarshalSSZToWriter(tc.payload, writer)
+			checkErr("marshal to failing writer", err)
+			if written != 0 || writer.written != 0 {
+				t.Errorf("marshal to failing writer: expected no written bytes, got %v", writer.written)
+			}
+
+			_, err = ds.SizeSSZ(tc.payload)
+			checkErr("size", err)
+
+			_, err = ds.HashTreeRoot(tc.payload)
+			checkErr("hash", err)
+		})
+	}
+
+	// fixed size vectors are always limited to their ssz-size
+	_, err := NewDynSsz(nil).HashTreeRoot(&strictLimitsTestPayload{Roots: make([][32]byte, 3)})
+	if err == nil || !contains(err.Error(), "strictLimitsTestPayload.Roots: list length is higher than max value (length: 3, size: 2)") {
+		t.Errorf("expected vector size error, got %v", err)
+	}
+}
+
+type loggerTestEntry struct {
+	depth int
+	msg   string
+	kv    []any
+}
+
+type loggerTestLogger struct {
+	entries []loggerTestEntry
+}
+
+func (l *loggerTestLogger) Log(depth int, msg string, kv ...any) {
+	l.entries = append(l.entries, loggerTestEntry{depth, msg, kv})
+}
+
+type loggerTestSwitchLogger struct {
+	loggerTestLogger
+	enabled bool
+}
+
+func (l *loggerTestSwitchLogger) Enabled() bool {
+	return l.enabled
+}
+
+func TestLogger(t *testing.T) {
+	payload := struct {
+		Slot uint64
+		Data []byte `ssz-max:"32"`
+	}{Slot: 1, Data: []byte{1, 2, 3}}
+
+	logger := &loggerTestLogger{}
+	ds := NewDynSsz(nil)
+	ds.Logger = logger
+
+	root, err := ds.HashTreeRoot(payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	fields := []string{}
+	for _, entry := range logger.entries {
+		if entry.msg == "field" {
+			fields = append(fields, fmt.Sprintf("%v:%v", entry.depth, entry.kv[1]))
+		}
+	}
+	if !reflect.DeepEqual(fields, []string{"0:Slot", "0:Data"}) {
+		t.Errorf("unexpected field entries: %v", fields)
+	}
+
+	last := logger.entries[len(logger.entries)-1]
+	if last.msg != "hash" || last.depth != 0 || last.kv[3] != fmt.Sprintf("0x%x", root) {
+		t.Errorf("unexpected root hash entry: %+v", last)
+	}
+
+	// the slog adapter logs at debug level and adds the depth as attribute
+	var buf bytes.Buffer
+	ds.Logger = NewSlogLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	if _, err := ds.MarshalSSZ(payload); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !contains(buf.String(), "level=DEBUG msg=\"marshal type\" depth=2 type=uint64 kind=uint64") {
+		t.Errorf("unexpected slog output: %v", buf.String())
+	}
+
+	buf.Reset()
+	ds.Logger = NewSlogLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+	if _, err := ds.MarshalSSZ(payload); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected no output below debug level, got %v", buf.String())
+	}
+
+	// loggers implementing Enabled are not traced to while disabled
+	switchLogger := &loggerTestSwitchLogger{}
+	ds.Logger = switchLogger
+	if _, err := ds.HashTreeRoot(payload); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(switchLogger.entries) != 0 {
+		t.Errorf("expected no entries while disabled, got %v", len(switchLogger.entries))
+	}
+
+	switchLogger.enabled = true
+	if _, err := ds.HashTreeRoot(payload); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(switchLogger.entries) == 0 {
+		t.Errorf("expected entries while enabled")
+	}
+
+	// the deprecated name based bitlist detection is reported to the logger
+	logger = &loggerTestLogger{}
+	ds = NewDynSsz(nil)
+	ds.Logger = logger
+	if _, err := ds.HashTreeRoot(struct {
+		Bits LegacyBitlist `ssz-max:"100"`
+	}{LegacyBitlist{0x03}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	found := false
+	for _, entry := range logger.entries {
+		if contains(entry.msg, "detected as bitlist by name") && entry.kv[1] == "dynssz_test.LegacyBitlist" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected deprecation notice for LegacyBitlist")
+	}
+}
+
+type pathErrorTestElement struct {
+	Index uint64
+	Flags [1]byte  `ssz-type:"bitvector" ssz-bitsize:"4"`
+	Blobs [][]byte `ssz-size:"2,?" ssz-max:"?,4"`
+}
+
+type pathErrorTestPayload struct {
+	Slot  uint64
+	Items []pathErrorTestElement `ssz-max:"8"`
+}
+
+type pathErrorTestUnion struct {
+	Union CompatibleUnion[struct {
+		Value   uint64
+		Element pathErrorTestElement
+	}]
+	Maybe Union[struct {
+		None    UnionNone
+		Element pathErrorTestElement
+	}]
+}
+
+func TestPathError(t *testing.T) {
+	ds := NewDynSsz(nil)
+
+	checkPathError := func(name string, err error, expectedPath string, expectedErr error) {
+		var pathErr *PathError
+		if !errors.As(err, &pathErr) {
+			t.Errorf("%v: expected PathError, got %v", name, err)
+			return
+		}
+		if pathErr.Path != expectedPath {
+			t.Errorf("%v: expected path %v, got %v", name, expectedPath, pathErr.Path)
+		}
+		if !errors.Is(err, expectedErr) {
+			t.Errorf("%v: expected error %v, got %v", name, expectedErr, pathErr.Err)
+		}
+	}
+
+	payload := &pathErrorTestPayload{
+		Slot: 1,
+		Items: []pathErrorTestElement{
+			{Index: 1, Flags: [1]byte{0x01}, Blobs: make([][]byte, 2)},
+			{Index: 2, Flags: [1]byte{0xf1}, Blobs: make([][]byte, 2)},
+		},
+	}
+
+	_, err := ds.HashTreeRoot(payload)
+	checkPathError("hash", err, "pathErrorTestPayload.Items[1].Flags", sszutils.ErrBitvectorPadding)
+
+	_, err = ds.MarshalSSZ(payload)
+	checkPathError("marshal", err, "pathErrorTestPayload.Items[1].Flags", sszutils.ErrBitvectorPadding)
+
+	payload.Items[1].Flags[0] = 0x01
+	payload.Items[0].Blobs = make([][]byte, 3)
+
+	_, err = ds.SizeSSZ(payload)
+	checkPathError("size", err, "pathErrorTestPayload.Items[0].Blobs", sszutils.ErrListTooBig)
+
+	payload.Items[0].Blobs = make([][]byte, 2)
+	payload.Items[1].Flags[0] = 0x0a
+
+	data, err := ds.MarshalSSZ(payload)
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+
+	// set the padding bits of the second item's flags
+	flagsOffset := bytes.LastIndexByte(data, 0x0a)
+	data[flagsOffset] = 0xfa
+
+	err = ds.UnmarshalSSZ(&pathErrorTestPayload{}, data)
+	checkPathError("unmarshal", err, "pathErrorTestPayload.Items[1].Flags", sszutils.ErrBitvectorPadding)
+
+	// unnamed root types are omitted from the path
+	_, err = ds.MarshalSSZ(&struct {
+		Flags [1]byte `ssz-type:"bitvector" ssz-bitsize:"4"`
+	}{Flags: [1]byte{0xf0}})
+	checkPathError("unnamed", err, "Flags", sszutils.ErrBitvectorPadding)
+
+	// union values continue the path with the Data field
+	unionPayload := &pathErrorTestUnion{}
+	unionPayload.Union.Variant = 1
+	unionPayload.Union.Data = pathErrorTestElement{Flags: [1]byte{0xf1}, Blobs: make([][]byte, 2)}
+	unionPayload.Maybe.Selector = 1
+	unionPayload.Maybe.Data = pathErrorTestElement{Flags: [1]byte{0x01}, Blobs: make([][]byte, 2)}
+
+	_, err = ds.HashTreeRoot(unionPayload)
+	checkPathError("compatible union hash", err, "pathErrorTestUnion.Union.Data.Flags", sszutils.ErrBitvectorPadding)
+
+	_, err = ds.MarshalSSZ(unionPayload)
+	checkPathError("compatible union marshal", err, "pathErrorTestUnion.Union.Data.Flags", sszutils.ErrBitvectorPadding)
+
+	unionPayload.Union.Data = uint64(1)
+	unionPayload.Union.Variant = 0
+	unionPayload.Maybe.Data = pathErrorTestElement{Flags: [1]byte{0x01}, Blobs: make([][]byte, 3)}
+
+	_, err = ds.SizeSSZ(unionPayload)
+	checkPathError("union size", err, "pathErrorTestUnion.Maybe.Data.Blobs", sszutils.ErrListTooBig)
+
+	unionPayload.Maybe.Data = pathErrorTestElement{Flags: [1]byte{0xf1}, Blobs: make([][]byte, 2)}
+
+	_, err = ds.HashTreeRoot(unionPayload)
+	checkPathError("union hash", err, "pathErrorTestUnion.Maybe.Data.Flags", sszutils.ErrBitvectorPadding)
+
+	_, err = ds.MarshalSSZ(unionPayload)
+	checkPathError("union marshal", err, "pathErrorTestUnion.Maybe.Data.Flags", sszutils.ErrBitvectorPadding)
+
+	unionPayload.Maybe.Data = pathErrorTestElement{Flags: [1]byte{0x0a}, Blobs: make([][]byte, 2)}
+	data, err = ds.MarshalSSZ(unionPayload)
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+	data[bytes.LastIndexByte(data, 0x0a)] = 0xfa
+
+	err = ds.UnmarshalSSZ(&pathErrorTestUnion{}, data)
+	checkPathError("union unmarshal", err, "pathErrorTestUnion.Maybe.Data.Flags", sszutils.ErrBitvectorPadding)
+}
+
+func TestUnionDataType(t *testing.T) {
+	ds := NewDynSsz(nil)
+
+	element := pathErrorTestElement{Index: 7, Flags: [1]byte{0x01}, Blobs: make([][]byte, 2)}
+	valuePayload := &pathErrorTestUnion{}
+	valuePayload.Union.Variant = 1
+	valuePayload.Union.Data = element
+	valuePayload.Maybe.Selector = 1
+	valuePayload.Maybe.Data = element
+
+	expectedData, err := ds.MarshalSSZ(valuePayload)
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+	expectedRoot, err := ds.HashTreeRoot(valuePayload)
+	if err != nil {
+		t.Fatalf("unexpected hash error: %v", err)
+	}
+
+	// pointers to the variant type are dereferenced
+	ptrPayload := &pathErrorTestUnion{}
+	ptrPayload.Union.Variant = 1
+	ptrPayload.Union.Data = &element
+	ptrPayload.Maybe.Selector = 1
+	ptrPayload.Maybe.Data = &element
+
+	data, err := ds.MarshalSSZ(ptrPayload)
+	if err != nil {
+		t.Fatalf("unexpected marshal error for pointer data: %v", err)
+	}
+	if !bytes.Equal(data, expectedData) {
+		t.Errorf("pointer data encoding mismatch: %x != %x", data, expectedData)
+	}
+
+	size, err := ds.SizeSSZ(ptrPayload)
+	if err != nil {
+		t.Fatalf("unexpected size error for pointer data: %v", err)
+	}
+	if size != len(expectedData) {
+		t.Errorf("pointer data size mismatch: %d != %d", size, len(expectedData))
+	}
+
+	root, err := ds.HashTreeRoot(ptrPayload)
+	if err != nil {
+		t.Fatalf("unexpected hash error for pointer data: %v", err)
+	}
+	if root != expectedRoot {
+		t.Errorf("pointer data root mismatch: %x != %x", root, expectedRoot)
+	}
+
+	// data of another type than the selected variant is rejected
+	wrongTypeCases := []struct {
+		name        string
+		payload     *pathErrorTestUnion
+		expectedErr string
+	}{
+		{
+			name: "compatible_union",
+			payload: &pathErrorTestUnion{
+				Union: CompatibleUnion[struct {
+					Value   uint64
+					Element pathErrorTestElement
+				}]{Variant: 1, Data: uint64(1)},
+				Maybe: valuePayload.Maybe,
+			},
+			expectedErr: "union variant 1: expected dynssz_test.pathErrorTestElement, got uint64",
+		},
+		{
+			name: "compatible_union_pointer",
+			payload: &pathErrorTestUnion{
+				Union: CompatibleUnion[struct {
+					Value   uint64
+					Element pathErrorTestElement
+				}]{Variant: 0, Data: &element},
+				Maybe: valuePayload.Maybe,
+			},
+			expectedErr: "union variant 0: expected uint64, got *dynssz_test.pathErrorTestElement",
+		},
+		{
+			name: "union",
+			payload: &pathErrorTestUnion{
+				Union: valuePayload.Union,
+				Maybe: Union[struct {
+					None    UnionNone
+					Element pathErrorTestElement
+				}]{Selector: 1, Data: uint32(1)},
+			},
+			expectedErr: "union variant 1: expected dynssz_test.pathErrorTestElement, got uint32",
+		},
+	}
+
+	for _, tc := range wrongTypeCases {
+		t.Run(tc.name, func(t *testing.T) {
+			checkErr := func(op string, err error) {
+				if err == nil {
+					t.Errorf("%v: expected error containing '%s', got no error", op, tc.expectedErr)
+				} else if !contains(err.Error(), tc.expectedErr) {
+					t.Errorf("%v: expected error containing '%s', got: %v", op, tc.expectedErr, err)
+				}
+			}
+
+			_, err := ds.MarshalSSZ(tc.payload)
+			checkErr("marshal", err)
+
+			_, err = ds.SizeSSZ(tc.payload)
+			checkErr("size", err)
+
+			_, err = ds.HashTreeRoot(tc.payload)
+			checkErr("hash", err)
+
+			_, err = ds.DumpTree(tc.payload)
+			checkErr("dump", err)
+		})
+	}
+}
@@ -0,0 +1,88 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// PathError describes an error that occurred while processing a nested value.
+//
+// It is returned by the encoding, decoding, sizing and hashing methods and carries the path of the
+// value that caused the error, like "BeaconState.Validators[3].Pubkey". The underlying error can
+// be inspected with errors.Is / errors.As.
+//
+// Example:
+//
+//	_, err := ds.HashTreeRoot(state)
+//	var pathErr *dynssz.PathError
+//	if errors.As(err, &pathErr) {
+//	    fmt.Printf("failed at %v: %v\n", pathErr.Path, pathErr.Err)
+//	}
+type PathError struct {
+	Path string // Path of the value that caused the error
+	Err  error  // The underlying error
+}
+
+// Error returns the error message prefixed with the path.
+func (e *PathError) Error() string {
+	return fmt.Sprintf("%v: %v", e.Path, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *PathError) Unwrap() error {
+	return e.Err
+}
+
+// prependErrorPath prepends a path segment to the path of err.
+// If err is not a PathError yet, a new PathError is created.
+func prependErrorPath(err error, segment string) error {
+	pathErr, ok := err.(*PathError)
+	if !ok {
+		return &PathError{
+			Path: segment,
+			Err:  err,
+		}
+	}
+
+	if strings.HasPrefix(pathErr.Path, "[") {
+		pathErr.Path = segment + pathErr.Path
+	} else {
+		pathErr.Path = segment + "." + pathErr.Path
+	}
+
+	return err
+}
+
+// wrapFieldError adds the name of a struct field to the error path.
+func wrapFieldError(err error, fieldName string) error {
+	return prependErrorPath(err, fieldName)
+}
+
+// wrapIndexError adds the index of a list or vector element to the error path.
+func wrapIndexError(err error, index int) error {
+	return prependErrorPath(err, "["+strconv.Itoa(index)+"]")
+}
+
+// wrapRootError adds the name of the root type to the error path.
+// Errors without a path (raised by the root value itself) and errors of unnamed root
+// types (e.g. anonymous structs) are returned unchanged.
+func wrapRootError(err error, t reflect.Type) error {
+	if _, ok := err.(*PathError); !ok {
+		return err
+	}
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Name() == "" {
+		return err
+	}
+
+	return prependErrorPath(err, t.Name())
+}
@@ -1,6 +1,7 @@
 package dynssz
 
 import (
+	"fmt"
 	"reflect"
 
 	"github.com/pk910/dynamic-ssz/sszutils"
@@ -13,6 +14,9 @@ var dynamicMarshalerType = reflect.TypeOf((*sszutils.DynamicMarshaler)(nil)).Ele
 var dynamicUnmarshalerType = reflect.TypeOf((*sszutils.DynamicUnmarshaler)(nil)).Elem()
 var dynamicSizerType = reflect.TypeOf((*sszutils.DynamicSizer)(nil)).Elem()
 var dynamicHashRootType = reflect.TypeOf((*sszutils.DynamicHashRoot)(nil)).Elem()
+var bitlistMarkerType = reflect.TypeOf((*sszutils.BitlistMarker)(nil)).Elem()
+var largeUintMarkerType = reflect.TypeOf((*sszutils.LargeUintMarker)(nil)).Elem()
+var stableContainerMarkerType = reflect.TypeOf((*sszutils.StableContainerMarker)(nil)).Elem()
 
 // getFastsszCompatibility evaluates the compatibility of a given type with fastssz, determining whether the type and its nested
 // structures can be efficiently encoded/decoded using fastssz's static code generation approach.
@@ -109,3 +113,52 @@ func (d *DynSsz) getDynamicHashRootCompatibility(targetType reflect.Type) bool {
 	targetPtrType := reflect.New(targetType).Type()
 	return targetPtrType.Implements(dynamicHashRootType)
 }
+
+// getBitlistMarkerCompatibility checks if a type implements the BitlistMarker interface
+func (d *DynSsz) getBitlistMarkerCompatibility(targetType reflect.Type) bool {
+	targetPtrType := reflect.New(targetType).Type()
+	return targetPtrType.Implements(bitlistMarkerType)
+}
+
+// getStableContainerCapacity returns the capacity of a type that implements the StableContainerMarker interface,
+// or 0 if the type does not implement it.
+func (d *DynSsz) getStableContainerCapacity(targetType reflect.Type) (uint32, error) {
+	targetPtrType := reflect.New(targetType).Type()
+	if !targetPtrType.Implements(stableContainerMarkerType) {
+		return 0, nil
+	}
+
+	marker := reflect.New(targetType).Interface().(sszutils.StableContainerMarker)
+	capacity := marker.SszStableCapacity()
+	if capacity <= 0 || capacity > 0xffffffff {
+		return 0, fmt.Errorf("invalid stable container capacity for type %v: %d", targetType, capacity)
+	}
+
+	return uint32(capacity), nil
+}
+
+// getLargeUintCompatibility checks if a type is a fixed-width little endian integer type, either by implementing the
+// LargeUintMarker interface or by being uint256.Int. It returns the matching ssz type (uint128 or uint256),
+// or SszUnspecifiedType if the type is not a large integer type.
+func (d *DynSsz) getLargeUintCompatibility(targetType reflect.Type) (SszType, error) {
+	// uint256.Int is a little endian [4]uint64 like the marker types, but it cannot implement LargeUintMarker
+	// and is not imported by this package, so it is the only type that is still matched by its name.
+	if targetType.PkgPath() == "github.com/holiman/uint256" && targetType.Name() == "Int" {
+		return SszUint256Type, nil
+	}
+
+	targetPtrType := reflect.New(targetType).Type()
+	if !targetPtrType.Implements(largeUintMarkerType) {
+		return SszUnspecifiedType, nil
+	}
+
+	marker := reflect.New(targetType).Interface().(sszutils.LargeUintMarker)
+	switch bits := marker.SszUintBits(); bits {
+	case 128:
+		return SszUint128Type, nil
+	case 256:
+		return SszUint256Type, nil
+	default:
+		return SszUnspecifiedType, fmt.Errorf("unsupported bit width for large uint type %v: %d (expected 128 or 256)", targetType, bits)
+	}
+}
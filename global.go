@@ -1,8 +1,14 @@
 package dynssz
 
+import "sync"
+
 var globalDynSsz *DynSsz
+var globalDynSszMutex sync.Mutex
 
 func GetGlobalDynSsz() *DynSsz {
+	globalDynSszMutex.Lock()
+	defer globalDynSszMutex.Unlock()
+
 	if globalDynSsz == nil {
 		globalDynSsz = NewDynSsz(nil)
 	}
@@ -10,5 +16,8 @@ func GetGlobalDynSsz() *DynSsz {
 }
 
 func SetGlobalSpecs(specs map[string]any) {
+	globalDynSszMutex.Lock()
+	defer globalDynSszMutex.Unlock()
+
 	globalDynSsz = NewDynSsz(specs)
 }
@@ -34,21 +34,12 @@ var DefaultHasherPool HasherPool
 // FastHasherPool is the fast hasher pool that uses the hashtree library if cgo is enabled
 var FastHasherPool HasherPool
 
-var hasherInitialized bool
-var hasherInitMutex sync.Mutex
+var hasherInitOnce sync.Once
 var zeroHashes [65][32]byte
 var zeroHashLevels map[string]int
 var trueBytes, falseBytes, zeroBytes []byte
 
 func initHasher() {
-	hasherInitMutex.Lock()
-	defer hasherInitMutex.Unlock()
-
-	if hasherInitialized {
-		return
-	}
-
-	hasherInitialized = true
 	falseBytes = make([]byte, 32)
 	trueBytes = make([]byte, 32)
 	zeroBytes = sszutils.ZeroBytes()
@@ -111,8 +102,17 @@ func (hh *HasherPool) Get() *Hasher {
 	return h.(*Hasher)
 }
 
+// maxPooledBufferSize is the maximum buffer capacity of hashers that are returned to the pool.
+// Hashers that grew beyond this size (e.g. after hashing a large state) are dropped to avoid
+// pinning large buffers in memory.
+const maxPooledBufferSize = 64 * 1024 * 1024
+
 // Put releases the Hasher to the pool.
 func (hh *HasherPool) Put(h *Hasher) {
+	if cap(h.buf) > maxPooledBufferSize {
+		return
+	}
+
 	h.Reset()
 	hh.pool.Put(h)
 }
@@ -141,9 +141,7 @@ func NewHasherWithHash(hh hash.Hash) *Hasher {
 
 // NewHasherWithHashFn creates a new Hasher object with a custom HashFn function
 func NewHasherWithHashFn(hh HashFn) *Hasher {
-	if !hasherInitialized {
-		initHasher()
-	}
+	hasherInitOnce.Do(initHasher)
 
 	return &Hasher{
 		hash: hh,
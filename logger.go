@@ -0,0 +1,88 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// Logger receives the trace output of the encoding, decoding and hashing traversal.
+//
+// Log is called once per traversal step. The depth is the indentation level of the step (it grows by
+// 2 for every nested value), msg describes the step and kv contains alternating key/value pairs with
+// the step details (type, kind, field name, hash, ...).
+//
+// A Logger may additionally implement Enabled() bool to report whether it currently records anything.
+// While it returns false, no trace messages are built and parallel hashing stays enabled.
+//
+// Example:
+//
+//	ds.Logger = dynssz.NewSlogLogger(slog.Default())
+type Logger interface {
+	Log(depth int, msg string, kv ...any)
+}
+
+// slogLogger forwards trace messages to a slog.Logger.
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlogLogger creates a Logger that forwards all trace messages to the given slog.Logger at debug level.
+// The depth is added as "depth" attribute.
+func NewSlogLogger(logger *slog.Logger) Logger {
+	return &slogLogger{
+		logger: logger,
+	}
+}
+
+// Log implements the Logger interface.
+func (l *slogLogger) Log(depth int, msg string, kv ...any) {
+	if !l.logger.Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
+
+	args := make([]any, 0, len(kv)+2)
+	args = append(args, "depth", depth)
+	args = append(args, kv...)
+	l.logger.Debug(msg, args...)
+}
+
+// Enabled reports whether the slog.Logger handles debug messages.
+func (l *slogLogger) Enabled() bool {
+	return l.logger.Enabled(context.Background(), slog.LevelDebug)
+}
+
+// logEnabled reports whether trace messages are logged.
+// Callers check it before building log messages, so there is no overhead while logging is disabled.
+func (d *DynSsz) logEnabled() bool {
+	if d.Logger == nil {
+		return d.Verbose
+	}
+
+	if enabler, ok := d.Logger.(interface{ Enabled() bool }); ok {
+		return enabler.Enabled()
+	}
+
+	return true
+}
+
+// log forwards a trace message to the Logger.
+// Without a Logger, the message is printed to stdout, which is the Verbose default.
+func (d *DynSsz) log(depth int, msg string, kv ...any) {
+	if d.Logger != nil {
+		d.Logger.Log(depth, msg, kv...)
+		return
+	}
+
+	var line strings.Builder
+	line.WriteString(strings.Repeat(" ", depth))
+	line.WriteString(msg)
+	for i := 0; i+1 < len(kv); i += 2 {
+		fmt.Fprintf(&line, "\t %v: %v", kv[i], kv[i+1])
+	}
+	fmt.Println(line.String())
+}
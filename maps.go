@@ -0,0 +1,59 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// getMapEntries returns the entries of a map as a list of {Key, Value} entry containers.
+//
+// The entries are sorted by key in ascending order, so the encoding of a map is deterministic
+// and does not depend on the iteration order of the go map.
+func getMapEntries(sourceType *TypeDescriptor, sourceValue reflect.Value) reflect.Value {
+	keys := sourceValue.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Uint() < keys[j].Uint()
+	})
+
+	entries := reflect.MakeSlice(sourceType.ElemDesc.Type, len(keys), len(keys))
+	for i, key := range keys {
+		entry := entries.Index(i)
+		entry.Field(0).Set(key)
+		entry.Field(1).Set(sourceValue.MapIndex(key))
+	}
+
+	return entries
+}
+
+// setMapEntries builds a map from a list of {Key, Value} entry containers and stores it in targetValue.
+//
+// To keep the encoding canonical, the keys must be strictly increasing. Unsorted or duplicate
+// keys are rejected.
+func setMapEntries(targetValue reflect.Value, entries reflect.Value) error {
+	entryCount := entries.Len()
+	newMap := reflect.MakeMapWithSize(targetValue.Type(), entryCount)
+
+	for i := 0; i < entryCount; i++ {
+		entry := entries.Index(i)
+		key := entry.Field(0)
+
+		if i > 0 {
+			prevKey := entries.Index(i - 1).Field(0).Uint()
+			if key.Uint() == prevKey {
+				return wrapIndexError(fmt.Errorf("duplicate map key %v", key.Uint()), i)
+			} else if key.Uint() < prevKey {
+				return wrapIndexError(fmt.Errorf("map keys are not sorted in ascending order (key %v after %v)", key.Uint(), prevKey), i)
+			}
+		}
+
+		newMap.SetMapIndex(key, entry.Field(1))
+	}
+
+	targetValue.Set(newMap)
+
+	return nil
+}
@@ -7,7 +7,6 @@ import (
 	"encoding/binary"
 	"fmt"
 	"reflect"
-	"strings"
 
 	"github.com/pk910/dynamic-ssz/sszutils"
 )
@@ -51,8 +50,8 @@ func (d *DynSsz) marshalType(sourceType *TypeDescriptor, sourceValue reflect.Val
 		useFastSsz = true
 	}
 
-	if d.Verbose {
-		fmt.Printf("%stype: %s\t kind: %v\t fastssz: %v (compat: %v/ dynamic: %v)\n", strings.Repeat(" ", idt), sourceType.Type.Name(), sourceType.Kind, useFastSsz, isFastsszMarshaler, hasDynamicSize)
+	if d.logEnabled() {
+		d.log(idt, "marshal type", "type", sourceType.Type.Name(), "kind", sourceType.Kind, "fastssz", useFastSsz, "compat", isFastsszMarshaler, "dynamicSize", hasDynamicSize)
 	}
 
 	if useFastSsz {
@@ -120,6 +119,26 @@ func (d *DynSsz) marshalType(sourceType *TypeDescriptor, sourceValue reflect.Val
 			if err != nil {
 				return nil, err
 			}
+		case SszUnionType:
+			buf, err = d.marshalUnion(sourceType, sourceValue, buf, idt)
+			if err != nil {
+				return nil, err
+			}
+		case SszOptionalType:
+			buf, err = d.marshalOptional(sourceType, sourceValue, buf, idt)
+			if err != nil {
+				return nil, err
+			}
+		case SszStableContainerType:
+			buf, err = d.marshalStableContainer(sourceType, sourceValue, buf, idt)
+			if err != nil {
+				return nil, err
+			}
+		case SszMapType:
+			buf, err = d.marshalMap(sourceType, sourceValue, buf, idt)
+			if err != nil {
+				return nil, err
+			}
 
 		// primitive types
 		case SszBoolType:
@@ -155,8 +174,8 @@ func (d *DynSsz) marshalType(sourceType *TypeDescriptor, sourceValue reflect.Val
 // The function validates that the Data field is present and marshals the wrapped value using its type descriptor.
 
 func (d *DynSsz) marshalTypeWrapper(sourceType *TypeDescriptor, sourceValue reflect.Value, buf []byte, idt int) ([]byte, error) {
-	if d.Verbose {
-		fmt.Printf("%smarshalTypeWrapper: %s\n", strings.Repeat(" ", idt), sourceType.Type.Name())
+	if d.logEnabled() {
+		d.log(idt, "marshalTypeWrapper", "type", sourceType.Type.Name())
 	}
 
 	// Extract the Data field from the TypeWrapper
@@ -203,7 +222,7 @@ func (d *DynSsz) marshalContainer(sourceType *TypeDescriptor, sourceValue reflec
 			fieldValue := sourceValue.Field(i)
 			newBuf, err := d.marshalType(field.Type, fieldValue, buf, idt+2)
 			if err != nil {
-				return nil, fmt.Errorf("failed encoding field %v: %v", field.Name, err)
+				return nil, wrapFieldError(err, field.Name)
 			}
 			buf = newBuf
 
@@ -227,7 +246,7 @@ func (d *DynSsz) marshalContainer(sourceType *TypeDescriptor, sourceValue reflec
 		bufLen := len(buf)
 		newBuf, err := d.marshalType(fieldDescriptor.Type, fieldValue, buf, idt+2)
 		if err != nil {
-			return nil, fmt.Errorf("failed decoding field %v: %v", fieldDescriptor.Name, err)
+			return nil, wrapFieldError(err, fieldDescriptor.Name)
 		}
 		buf = newBuf
 		offset += len(buf) - bufLen
@@ -236,6 +255,41 @@ func (d *DynSsz) marshalContainer(sourceType *TypeDescriptor, sourceValue reflec
 	return buf, nil
 }
 
+// validateBitvectorPadding checks that the padding bits of a bitvector with a bit length that is not
+// a multiple of 8 are zero, so marshalling, unmarshalling and hashing agree on the same representation.
+func validateBitvectorPadding(sourceType *TypeDescriptor, sourceValue reflect.Value) error {
+	if sourceType.BitSize%8 == 0 || sourceValue.Len() < int(sourceType.Len) {
+		return nil
+	}
+
+	lastByte := byte(sourceValue.Index(int(sourceType.Len) - 1).Uint())
+	return sszutils.ValidateBitvectorPadding([]byte{lastByte}, uint64(sourceType.BitSize%8))
+}
+
+// validateVectorLength checks that a vector value does not contain more elements than the vector size.
+func validateVectorLength(sourceType *TypeDescriptor, sliceLen int) error {
+	if uint32(sliceLen) > sourceType.Len {
+		return fmt.Errorf("%w (length: %d, size: %d)", sszutils.ErrListTooBig, sliceLen, sourceType.Len)
+	}
+
+	return nil
+}
+
+// validateListLimit checks that a list value does not exceed its ssz-max limit.
+// The check is only performed in StrictLimits mode. Bitlists are skipped, as their limit is a bit count
+// that is always validated while hashing.
+func (d *DynSsz) validateListLimit(sourceType *TypeDescriptor, sliceLen int) error {
+	if !d.StrictLimits || sourceType.SszType != SszListType || sourceType.SszTypeFlags&SszTypeFlagHasLimit == 0 {
+		return nil
+	}
+
+	if uint64(sliceLen) > sourceType.Limit {
+		return fmt.Errorf("%w (length: %d, max: %d)", sszutils.ErrListTooBig, sliceLen, sourceType.Limit)
+	}
+
+	return nil
+}
+
 // marshalVector encodes vector values into SSZ-encoded data.
 //
 // Vectors in SSZ are encoded as fixed-size sequences where each element is encoded
@@ -259,8 +313,14 @@ func (d *DynSsz) marshalContainer(sourceType *TypeDescriptor, sourceValue reflec
 
 func (d *DynSsz) marshalVector(sourceType *TypeDescriptor, sourceValue reflect.Value, buf []byte, idt int) ([]byte, error) {
 	sliceLen := sourceValue.Len()
-	if uint32(sliceLen) > sourceType.Len {
-		return nil, sszutils.ErrListTooBig
+	if err := validateVectorLength(sourceType, sliceLen); err != nil {
+		return nil, err
+	}
+
+	if sourceType.SszType == SszBitvectorType {
+		if err := validateBitvectorPadding(sourceType, sourceValue); err != nil {
+			return nil, err
+		}
 	}
 
 	appendZero := 0
@@ -294,7 +354,7 @@ func (d *DynSsz) marshalVector(sourceType *TypeDescriptor, sourceValue reflect.V
 			itemVal := sourceValue.Index(i)
 			newBuf, err := d.marshalType(sourceType.ElemDesc, itemVal, buf, idt+2)
 			if err != nil {
-				return nil, err
+				return nil, wrapIndexError(err, i)
 			}
 			buf = newBuf
 		}
@@ -338,8 +398,8 @@ func (d *DynSsz) marshalDynamicVector(sourceType *TypeDescriptor, sourceValue re
 	appendZero := 0
 	if sourceType.Kind == reflect.Slice || sourceType.Kind == reflect.String {
 		sliceLen := sourceValue.Len()
-		if uint32(sliceLen) > sourceType.Len {
-			return nil, sszutils.ErrListTooBig
+		if err := validateVectorLength(sourceType, sliceLen); err != nil {
+			return nil, err
 		}
 		if uint32(sliceLen) < sourceType.Len {
 			appendZero = int(sourceType.Len) - sliceLen
@@ -358,7 +418,7 @@ func (d *DynSsz) marshalDynamicVector(sourceType *TypeDescriptor, sourceValue re
 
 		newBuf, err := d.marshalType(fieldType, itemVal, buf, idt+2)
 		if err != nil {
-			return nil, err
+			return nil, wrapIndexError(err, i)
 		}
 		newBufLen := len(newBuf)
 		buf = newBuf
@@ -416,6 +476,10 @@ func (d *DynSsz) marshalDynamicVector(sourceType *TypeDescriptor, sourceValue re
 //   - Returns ErrListTooBig if slice exceeds maximum size from hints
 
 func (d *DynSsz) marshalList(sourceType *TypeDescriptor, sourceValue reflect.Value, buf []byte, idt int) ([]byte, error) {
+	if err := d.validateListLimit(sourceType, sourceValue.Len()); err != nil {
+		return nil, err
+	}
+
 	if sourceType.GoTypeFlags&GoTypeFlagIsString != 0 {
 		stringBytes := []byte(sourceValue.String())
 		buf = append(buf, stringBytes...)
@@ -435,7 +499,7 @@ func (d *DynSsz) marshalList(sourceType *TypeDescriptor, sourceValue reflect.Val
 
 			newBuf, err := d.marshalType(fieldType, itemVal, buf, idt+2)
 			if err != nil {
-				return nil, err
+				return nil, wrapIndexError(err, i)
 			}
 			buf = newBuf
 		}
@@ -467,6 +531,10 @@ func (d *DynSsz) marshalDynamicList(sourceType *TypeDescriptor, sourceValue refl
 	fieldType := sourceType.ElemDesc
 	sliceLen := sourceValue.Len()
 
+	if err := d.validateListLimit(sourceType, sliceLen); err != nil {
+		return nil, err
+	}
+
 	startOffset := len(buf)
 	totalOffsets := sliceLen
 	buf = sszutils.AppendZeroPadding(buf, 4*totalOffsets) // Reserve space for offsets
@@ -479,7 +547,7 @@ func (d *DynSsz) marshalDynamicList(sourceType *TypeDescriptor, sourceValue refl
 
 		newBuf, err := d.marshalType(fieldType, itemVal, buf, idt+2)
 		if err != nil {
-			return nil, err
+			return nil, wrapIndexError(err, i)
 		}
 		newBufLen := len(newBuf)
 		buf = newBuf
@@ -524,11 +592,190 @@ func (d *DynSsz) marshalCompatibleUnion(sourceType *TypeDescriptor, sourceValue
 		return nil, sszutils.ErrInvalidUnionVariant
 	}
 
+	dataValue, err := getUnionVariantValue(variantDesc, variant, dataField)
+	if err != nil {
+		return nil, err
+	}
+
 	// Marshal the data using the variant's type descriptor
-	newBuf, err := d.marshalType(variantDesc, dataField.Elem(), buf, idt+2)
+	newBuf, err := d.marshalType(variantDesc, dataValue, buf, idt+2)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal union variant %d: %w", variant, err)
+		return nil, wrapFieldError(err, "Data")
 	}
 
 	return newBuf, nil
 }
+
+// marshalUnion encodes Union values into SSZ-encoded data.
+//
+// According to the spec:
+// - The encoding is: selector.to_bytes(1, "little") + serialize(value.value)
+// - The None option (selector 0) is encoded as the selector byte only
+//
+// Parameters:
+//   - sourceType: The TypeDescriptor containing union metadata and variant descriptors
+//   - sourceValue: The reflect.Value of the Union to encode
+//   - buf: The buffer to append encoded data to
+//   - idt: Indentation level for verbose logging
+//
+// Returns:
+//   - []byte: The updated buffer with the encoded union
+//   - error: An error if encoding fails
+func (d *DynSsz) marshalUnion(sourceType *TypeDescriptor, sourceValue reflect.Value, buf []byte, idt int) ([]byte, error) {
+	// Union has exactly 2 fields: Selector (uint8) and Data (interface{})
+	selector := uint8(sourceValue.Field(0).Uint())
+	dataField := sourceValue.Field(1)
+
+	variantDesc, ok := sourceType.UnionVariants[selector]
+	if !ok {
+		return nil, sszutils.ErrInvalidUnionVariant
+	}
+
+	buf = append(buf, selector)
+
+	if variantDesc == nil {
+		// None option, no data
+		if !dataField.IsNil() {
+			return nil, fmt.Errorf("union None option must not carry data, got %v", dataField.Elem().Type())
+		}
+		return buf, nil
+	}
+
+	dataValue, err := getUnionVariantValue(variantDesc, selector, dataField)
+	if err != nil {
+		return nil, err
+	}
+
+	newBuf, err := d.marshalType(variantDesc, dataValue, buf, idt+2)
+	if err != nil {
+		return nil, wrapFieldError(err, "Data")
+	}
+
+	return newBuf, nil
+}
+
+// marshalOptional encodes Optional values into SSZ-encoded data.
+//
+// According to EIP-6475:
+// - An absent value (nil pointer) is encoded as an empty byte sequence
+// - A present value is encoded as 0x01 + serialize(value)
+//
+// Parameters:
+//   - sourceType: The TypeDescriptor containing the optional's value descriptor
+//   - sourceValue: The reflect.Value of the optional pointer to encode
+//   - buf: The buffer to append encoded data to
+//   - idt: Indentation level for verbose logging
+//
+// Returns:
+//   - []byte: The updated buffer with the encoded optional
+//   - error: An error if encoding fails
+func (d *DynSsz) marshalOptional(sourceType *TypeDescriptor, sourceValue reflect.Value, buf []byte, idt int) ([]byte, error) {
+	if sourceValue.IsNil() {
+		return buf, nil
+	}
+
+	buf = append(buf, 1)
+
+	newBuf, err := d.marshalType(sourceType.ElemDesc, sourceValue.Elem(), buf, idt+2)
+	if err != nil {
+		return nil, err
+	}
+
+	return newBuf, nil
+}
+
+// marshalStableContainer encodes stable container values into SSZ-encoded data.
+//
+// According to EIP-7495:
+// - The encoding is: serialize(active_fields) + serialize(active field values as container)
+// - active_fields is a Bitvector[N] with the capacity N, where a bit is set for each present (non-nil) field
+// - Absent fields are omitted from the serialization entirely
+//
+// Parameters:
+//   - sourceType: The TypeDescriptor containing stable container metadata
+//   - sourceValue: The reflect.Value of the stable container to encode
+//   - buf: The buffer to append encoded data to
+//   - idt: Indentation level for verbose logging
+//
+// Returns:
+//   - []byte: The updated buffer with the encoded stable container
+//   - error: An error if encoding fails
+func (d *DynSsz) marshalStableContainer(sourceType *TypeDescriptor, sourceValue reflect.Value, buf []byte, idt int) ([]byte, error) {
+	fields := sourceType.ContainerDesc.Fields
+	activeFields := make([]byte, (sourceType.Len+7)/8)
+	for i := range fields {
+		if !sourceValue.Field(i).IsNil() {
+			activeFields[i/8] |= 1 << (i % 8)
+		}
+	}
+	buf = append(buf, activeFields...)
+
+	// fixed part of the active fields
+	offset := 0
+	startLen := len(buf)
+	dynOffsets := make([]int, 0, len(sourceType.ContainerDesc.DynFields))
+	for i, field := range fields {
+		fieldValue := sourceValue.Field(i)
+		if fieldValue.IsNil() {
+			continue
+		}
+
+		if field.Type.Size > 0 {
+			newBuf, err := d.marshalType(field.Type, fieldValue, buf, idt+2)
+			if err != nil {
+				return nil, wrapFieldError(err, field.Name)
+			}
+			buf = newBuf
+			offset += int(field.Type.Size)
+		} else {
+			dynOffsets = append(dynOffsets, offset)
+			buf = binary.LittleEndian.AppendUint32(buf, 0)
+			offset += 4
+		}
+	}
+
+	// dynamic part of the active fields
+	dynIndex := 0
+	for i, field := range fields {
+		fieldValue := sourceValue.Field(i)
+		if fieldValue.IsNil() || field.Type.Size > 0 {
+			continue
+		}
+
+		fieldOffset := dynOffsets[dynIndex] + startLen
+		binary.LittleEndian.PutUint32(buf[fieldOffset:fieldOffset+4], uint32(offset))
+		dynIndex++
+
+		bufLen := len(buf)
+		newBuf, err := d.marshalType(field.Type, fieldValue, buf, idt+2)
+		if err != nil {
+			return nil, wrapFieldError(err, field.Name)
+		}
+		buf = newBuf
+		offset += len(buf) - bufLen
+	}
+
+	return buf, nil
+}
+
+// marshalMap encodes a map into SSZ-encoded data.
+//
+// Maps are encoded as a list of {Key, Value} entry containers, sorted by key in ascending order.
+//
+// Parameters:
+//   - sourceType: The TypeDescriptor containing the map's entry list descriptor
+//   - sourceValue: The reflect.Value of the map to encode
+//   - buf: The buffer to append encoded data to
+//   - idt: Indentation level for verbose logging
+//
+// Returns:
+//   - []byte: The updated buffer with the encoded map entries
+//   - error: An error if encoding fails or the map exceeds its max size
+func (d *DynSsz) marshalMap(sourceType *TypeDescriptor, sourceValue reflect.Value, buf []byte, idt int) ([]byte, error) {
+	if uint64(sourceValue.Len()) > sourceType.Limit {
+		return nil, sszutils.ErrListTooBig
+	}
+
+	entries := getMapEntries(sourceType, sourceValue)
+	return d.marshalType(sourceType.ElemDesc, entries, buf, idt+2)
+}
@@ -5,6 +5,7 @@ package dynssz_test
 
 import (
 	"bytes"
+	"errors"
 	"testing"
 
 	. "github.com/pk910/dynamic-ssz"
@@ -201,6 +202,56 @@ var marshalTestMatrix = []struct {
 		}]{Variant: 0, Data: uint32(0x12345678)}, 0x4242},
 		fromHex("0x37130800000042420078563412"),
 	},
+
+	// Union tests (selector 0 is the None option)
+	{
+		struct {
+			A uint16
+			B Union[struct {
+				None  UnionNone
+				Value uint32
+				List  []uint8 `ssz-max:"8"`
+			}]
+			C uint16
+		}{0x1337, Union[struct {
+			None  UnionNone
+			Value uint32
+			List  []uint8 `ssz-max:"8"`
+		}]{Selector: 0}, 0x4242},
+		fromHex("0x371308000000424200"),
+	},
+	{
+		struct {
+			A uint16
+			B Union[struct {
+				None  UnionNone
+				Value uint32
+				List  []uint8 `ssz-max:"8"`
+			}]
+			C uint16
+		}{0x1337, Union[struct {
+			None  UnionNone
+			Value uint32
+			List  []uint8 `ssz-max:"8"`
+		}]{Selector: 1, Data: uint32(0x12345678)}, 0x4242},
+		fromHex("0x37130800000042420178563412"),
+	},
+	{
+		struct {
+			A uint16
+			B Union[struct {
+				None  UnionNone
+				Value uint32
+				List  []uint8 `ssz-max:"8"`
+			}]
+			C uint16
+		}{0x1337, Union[struct {
+			None  UnionNone
+			Value uint32
+			List  []uint8 `ssz-max:"8"`
+		}]{Selector: 2, Data: []uint8{1, 2, 3}}, 0x4242},
+		fromHex("0x371308000000424202010203"),
+	},
 	{
 		[2]uint16{1, 2},
 		fromHex("0x01000200"),
@@ -273,6 +324,25 @@ var marshalTestMatrix = []struct {
 		}{[4]byte{0xff, 0x0f, 0x00, 0xf0}},
 		fromHex("0xff0f00f0"),
 	},
+	{
+		struct {
+			Flags [2]byte `ssz-bitsize:"12"`
+		}{[2]byte{0xff, 0x0f}},
+		fromHex("0xff0f"),
+	},
+	{
+		struct {
+			Flags [2]byte `ssz-size:"2" ssz-bitsize:"12"`
+		}{[2]byte{0xff, 0x0f}},
+		fromHex("0xff0f"),
+	},
+	{
+		struct {
+			Flags []byte `ssz-type:"bitvector" ssz-bitsize:"12"`
+			Value uint16
+		}{[]byte{0x01, 0x08}, 0x1337},
+		fromHex("0x01083713"),
+	},
 
 	// explicit basic type annotations
 	{
@@ -310,6 +380,130 @@ var marshalTestMatrix = []struct {
 		fromHex("0x64000000c800000000000000"),
 	},
 
+	// Optional tests (EIP-6475)
+	{
+		struct {
+			A uint16
+			B *uint64 `ssz-type:"optional"`
+			C uint16
+		}{0x1337, nil, 0x4242},
+		fromHex("0x3713080000004242"),
+	},
+	{
+		struct {
+			A uint16
+			B *uint64 `ssz-type:"optional"`
+			C uint16
+		}{0x1337, func() *uint64 { v := uint64(0x1122); return &v }(), 0x4242},
+		fromHex("0x3713080000004242012211000000000000"),
+	},
+	{
+		struct {
+			A uint16
+			B *[]uint8 `ssz-type:"optional" ssz-max:"8"`
+			C uint16
+		}{0x1337, &[]uint8{1, 2, 3}, 0x4242},
+		fromHex("0x371308000000424201010203"),
+	},
+
+	// StableContainer tests (EIP-7495)
+	{
+		struct {
+			A uint16
+			S struct {
+				X *uint16
+				Y *[]uint8 `ssz-max:"8"`
+				Z *uint32
+			} `ssz-type:"stable-container" ssz-size:"4"`
+			C uint16
+		}{A: 0x1337, C: 0x4242},
+		fromHex("0x371308000000424200"),
+	},
+	{
+		struct {
+			A uint16
+			S struct {
+				X *uint16
+				Y *[]uint8 `ssz-max:"8"`
+				Z *uint32
+			} `ssz-type:"stable-container" ssz-size:"4"`
+			C uint16
+		}{A: 0x1337, C: 0x4242, S: struct {
+			X *uint16
+			Y *[]uint8 `ssz-max:"8"`
+			Z *uint32
+		}{X: func() *uint16 { v := uint16(0x1122); return &v }(), Y: &[]uint8{1, 2, 3}}},
+		fromHex("0x371308000000424203221106000000010203"),
+	},
+
+	// multi-dimensional lists and vectors (one size/max hint per dimension)
+	{
+		struct {
+			A [][][]byte `ssz-max:"4,8,16"`
+		}{[][][]byte{{{1, 2}, {3}}, {}, {{4, 5, 6}}}},
+		fromHex("0x040000000c0000001700000017000000080000000a00000001020304000000040506"),
+	},
+	{
+		struct {
+			A [][][]uint16 `ssz-size:"?,2,?" ssz-max:"4,?,8"`
+		}{[][][]uint16{{{1}, {2, 3}}, {{}, {4}}}},
+		fromHex("0x040000000800000016000000080000000a00000001000200030008000000080000000400"),
+	},
+	{
+		struct {
+			A [2][][]byte `ssz-max:"?,4,16"`
+		}{[2][][]byte{{{1}, {2, 3}}, {}}},
+		fromHex("0x0400000008000000130000000800000009000000010203"),
+	},
+	{
+		struct {
+			A []struct {
+				X [][]byte `ssz-max:"2,4"`
+				Y uint8
+			} `ssz-max:"3"`
+		}{[]struct {
+			X [][]byte `ssz-max:"2,4"`
+			Y uint8
+		}{{X: [][]byte{{1, 2}, {3}}, Y: 7}, {X: [][]byte{}, Y: 8}}},
+		fromHex("0x0400000008000000180000000500000007080000000a0000000102030500000008"),
+	},
+	{
+		struct {
+			A [2][2][]uint8 `ssz-max:"?,?,4"`
+			B uint16
+		}{[2][2][]uint8{{{1}, {}}, {{2, 3}, {4}}}, 0x1337},
+		fromHex("0x0600000037130800000011000000080000000900000001080000000a000000020304"),
+	},
+	{
+		struct {
+			A [][][3]uint16 `ssz-max:"4,8"`
+		}{[][][3]uint16{{{1, 2, 3}, {4, 5, 6}}, {}, {{7, 8, 9}}}},
+		fromHex("0x040000000c0000001800000018000000010002000300040005000600070008000900"),
+	},
+
+	// map types (sorted list of key/value entries)
+	{
+		struct {
+			A uint16
+			M map[uint64]uint16 `ssz-max:"4"`
+			C uint16
+		}{0x1337, map[uint64]uint16{5: 0x3344, 1: 0x1122, 3: 0x5566}, 0x4242},
+		fromHex("0x3713080000004242010000000000000022110300000000000000665505000000000000004433"),
+	},
+	{
+		struct {
+			A uint16
+			M map[uint32][]uint8 `ssz-max:"4,8"`
+		}{0x1337, map[uint32][]uint8{2: {1, 2}, 1: {}}},
+		fromHex("0x3713060000000800000010000000010000000800000002000000080000000102"),
+	},
+	{
+		struct {
+			M map[uint64]uint16 `ssz-max:"4"`
+		}{nil},
+		fromHex("0x04000000"),
+	},
+
 	// string types
 	{
 		struct {
@@ -424,6 +618,205 @@ func TestMarshalTo(t *testing.T) {
 	}
 }
 
+func TestMarshalToWriter(t *testing.T) {
+	for _, noFastSsz := range []bool{false, true} {
+		dynssz := NewDynSsz(nil)
+		dynssz.NoFastSsz = noFastSsz
+
+		for idx, test := range marshalTestMatrix {
+			var buf bytes.Buffer
+			written, err := dynssz.MarshalSSZToWriter(test.payload, &buf)
+
+			switch {
+			case test.expected == nil && err != nil:
+				// expected error
+			case err != nil:
+				t.Errorf("test %v error: %v", idx, err)
+			case !bytes.Equal(buf.Bytes(), test.expected):
+				t.Errorf("test %v failed (nofastssz: %v): got 0x%x, wanted 0x%x", idx, noFastSsz, buf.Bytes(), test.expected)
+			case written != len(test.expected):
+				t.Errorf("test %v failed (nofastssz: %v): got %v written bytes, wanted %v", idx, noFastSsz, written, len(test.expected))
+			}
+		}
+	}
+}
+
+type failingWriter struct {
+	limit   int
+	written int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.written+len(p) > w.limit {
+		n := w.limit - w.written
+		w.written = w.limit
+		return n, errors.New("disk full")
+	}
+	w.written += len(p)
+	return len(p), nil
+}
+
+func TestMarshalToWriterLarge(t *testing.T) {
+	type element struct {
+		Index uint64
+		Data  []byte `ssz-max:"64"`
+	}
+	payload := struct {
+		Slot     uint64
+		Elements []element  `ssz-max:"10000"`
+		Roots    [][32]byte `ssz-size:"8192"`
+		Values   []uint64   `ssz-max:"10000"`
+	}{Slot: 1}
+	for i := 0; i < 5000; i++ {
+		payload.Elements = append(payload.Elements, element{Index: uint64(i), Data: bytes.Repeat([]byte{byte(i)}, i%64)})
+		payload.Values = append(payload.Values, uint64(i))
+	}
+	payload.Roots = make([][32]byte, 100)
+	payload.Roots[99] = [32]byte{1}
+
+	ds := NewDynSsz(nil)
+	expected, err := ds.MarshalSSZ(payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	written, err := ds.MarshalSSZToWriter(payload, &buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if written != len(expected) || !bytes.Equal(buf.Bytes(), expected) {
+		t.Errorf("streamed encoding does not match in-memory encoding (%v / %v bytes)", written, len(expected))
+	}
+
+	// writer errors abort the encoding and report the bytes written so far
+	writer := &failingWriter{limit: 100000}
+	written, err = ds.MarshalSSZToWriter(payload, writer)
+	if err == nil || !contains(err.Error(), "disk full") {
+		t.Errorf("expected writer error, got %v", err)
+	}
+	if written != 100000 {
+		t.Errorf("expected 100000 written bytes, got %v", written)
+	}
+
+	// size violations are detected before any data is written
+	payload.Roots = make([][32]byte, 8193)
+	writer = &failingWriter{limit: len(expected) * 2}
+	written, err = ds.MarshalSSZToWriter(payload, writer)
+	if err == nil || !contains(err.Error(), "Roots: list length is higher than max value") {
+		t.Errorf("expected size error, got %v", err)
+	}
+	if written != 0 || writer.written != 0 {
+		t.Errorf("expected no written bytes, got %v", written)
+	}
+}
+
+func TestSizeSSZ(t *testing.T) {
+	for _, noFastSsz := range []bool{false, true} {
+		dynssz := NewDynSsz(nil)
+		dynssz.NoFastSsz = noFastSsz
+
+		for idx, test := range marshalTestMatrix {
+			if test.expected == nil {
+				continue
+			}
+
+			size, err := dynssz.SizeSSZ(test.payload)
+			switch {
+			case err != nil:
+				t.Errorf("test %v error: %v", idx, err)
+			case size != len(test.expected):
+				t.Errorf("test %v failed (nofastssz: %v): got size %v, wanted %v", idx, noFastSsz, size, len(test.expected))
+			}
+		}
+	}
+}
+
+func TestSizeSSZSpecValues(t *testing.T) {
+	type Payload struct {
+		A uint64
+		B []uint16   `ssz-size:"4" dynssz-size:"VECTOR_SIZE"`
+		C [][]uint8  `ssz-max:"8" dynssz-max:"MAX_ITEMS"`
+		D [][2]uint8 `ssz-size:"2,?" dynssz-size:"VECTOR_SIZE,?"`
+	}
+
+	payload := Payload{
+		A: 1,
+		B: []uint16{1, 2},
+		C: [][]uint8{{1, 2, 3}, {}},
+		D: [][2]uint8{{1, 2}},
+	}
+
+	testCases := []struct {
+		specs        map[string]any
+		expectedSize int
+	}{
+		// 8 (A) + 4*2 (B) + 4 (C offset) + 2*4+3 (C) + 2*2 (D)
+		{nil, 35},
+		// 8 (A) + 6*2 (B) + 4 (C offset) + 2*4+3 (C) + 6*2 (D)
+		{map[string]any{"VECTOR_SIZE": uint64(6), "MAX_ITEMS": uint64(4)}, 47},
+	}
+
+	for _, tc := range testCases {
+		dynssz := NewDynSsz(tc.specs)
+
+		size, err := dynssz.SizeSSZ(payload)
+		if err != nil {
+			t.Fatalf("specs %v: unexpected error: %v", tc.specs, err)
+		}
+		if size != tc.expectedSize {
+			t.Errorf("specs %v: got size %v, wanted %v", tc.specs, size, tc.expectedSize)
+		}
+
+		buf, err := dynssz.MarshalSSZ(payload)
+		if err != nil {
+			t.Fatalf("specs %v: unexpected marshal error: %v", tc.specs, err)
+		}
+		if len(buf) != size {
+			t.Errorf("specs %v: size %v does not match marshalled length %v", tc.specs, size, len(buf))
+		}
+	}
+}
+
+func TestSizeSSZErrors(t *testing.T) {
+	dynssz := NewDynSsz(nil)
+
+	testCases := []struct {
+		name        string
+		input       any
+		expectedErr string
+	}{
+		{
+			name:        "nil_value",
+			input:       nil,
+			expectedErr: "cannot determine type of nil value",
+		},
+		{
+			name:        "unsupported_kind",
+			input:       map[string]uint64{},
+			expectedErr: "map ssz type can only be represented by maps with unsigned integer keys",
+		},
+		{
+			name: "dynamic_vector_too_big",
+			input: struct {
+				Data [][]byte `ssz-size:"1,?" ssz-max:"?,8"`
+			}{[][]byte{{1}, {2}}},
+			expectedErr: "list length is higher than max value",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := dynssz.SizeSSZ(tc.input)
+			if err == nil {
+				t.Errorf("expected error containing '%s', but got no error", tc.expectedErr)
+			} else if !contains(err.Error(), tc.expectedErr) {
+				t.Errorf("expected error containing '%s', but got '%s'", tc.expectedErr, err.Error())
+			}
+		})
+	}
+}
+
 func TestStringVsByteContainerMarshalEquivalence(t *testing.T) {
 	type StringContainer struct {
 		Data string `ssz-max:"100"`
@@ -574,6 +967,141 @@ func TestMarshalErrors(t *testing.T) {
 			}{[]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31, 32, 33}},
 			expectedErr: "list length is higher than max value",
 		},
+		{
+			name: "optional_without_pointer",
+			input: struct {
+				Value uint64 `ssz-type:"optional"`
+			}{},
+			expectedErr: "optional ssz type can only be represented by pointer types",
+		},
+		{
+			name: "stable_container_without_capacity",
+			input: struct {
+				Value struct {
+					A *uint64
+				} `ssz-type:"stable-container"`
+			}{},
+			expectedErr: "stable container ssz type requires a capacity",
+		},
+		{
+			name: "stable_container_non_pointer_field",
+			input: struct {
+				Value struct {
+					A uint64
+				} `ssz-type:"stable-container" ssz-size:"4"`
+			}{},
+			expectedErr: "stable container field A must be a pointer type",
+		},
+		{
+			name: "stable_container_exceeds_capacity",
+			input: struct {
+				Value struct {
+					A *uint64
+					B *uint64
+				} `ssz-type:"stable-container" ssz-size:"1"`
+			}{},
+			expectedErr: "stable container has more fields than its capacity",
+		},
+		{
+			name: "map_without_max_size",
+			input: struct {
+				M map[uint64]uint16
+			}{},
+			expectedErr: "map ssz type requires a max size (ssz-max tag)",
+		},
+		{
+			name: "map_with_fixed_size",
+			input: struct {
+				M map[uint64]uint16 `ssz-size:"2" ssz-max:"2"`
+			}{},
+			expectedErr: "map ssz type does not support a fixed size (ssz-size tag)",
+		},
+		{
+			name: "map_too_many_entries",
+			input: struct {
+				M map[uint64]uint16 `ssz-max:"2"`
+			}{map[uint64]uint16{1: 1, 2: 2, 3: 3}},
+			expectedErr: "list length is higher than max value",
+		},
+		{
+			name: "union_none_not_first",
+			input: struct {
+				U Union[struct {
+					Value uint32
+					None  UnionNone
+				}]
+			}{},
+			expectedErr: "union None option is only allowed as the first option, got selector 1",
+		},
+		{
+			name: "union_none_only",
+			input: struct {
+				U Union[struct {
+					None UnionNone
+				}]
+			}{},
+			expectedErr: "union type requires at least one non-None option",
+		},
+		{
+			name: "union_invalid_selector",
+			input: struct {
+				U Union[struct {
+					None  UnionNone
+					Value uint32
+				}]
+			}{Union[struct {
+				None  UnionNone
+				Value uint32
+			}]{Selector: 2, Data: uint32(1)}},
+			expectedErr: "U: unknown union variant index: 2",
+		},
+		{
+			name: "union_none_with_data",
+			input: struct {
+				U Union[struct {
+					None  UnionNone
+					Value uint32
+				}]
+			}{Union[struct {
+				None  UnionNone
+				Value uint32
+			}]{Selector: 0, Data: uint32(1)}},
+			expectedErr: "union None option must not carry data, got uint32",
+		},
+		{
+			name: "union_missing_data",
+			input: struct {
+				U Union[struct {
+					None  UnionNone
+					Value uint32
+				}]
+			}{Union[struct {
+				None  UnionNone
+				Value uint32
+			}]{Selector: 1}},
+			expectedErr: "missing data for union variant 1",
+		},
+		{
+			name: "bitvector_padding_bits",
+			input: struct {
+				Flags [2]byte `ssz-bitsize:"12"`
+			}{[2]byte{0xff, 0x1f}},
+			expectedErr: "bitvector padding bits are not zero",
+		},
+		{
+			name: "bitsize_size_mismatch",
+			input: struct {
+				Flags [2]byte `ssz-size:"2" ssz-bitsize:"20"`
+			}{},
+			expectedErr: "conflicting size tags for 'Flags' field: 20 bits do not match 2 bytes",
+		},
+		{
+			name: "bitsize_on_vector",
+			input: struct {
+				Values [2]uint64 `ssz-type:"vector" ssz-bitsize:"12"`
+			}{},
+			expectedErr: "bit size hint is only supported for bitvector types",
+		},
 		{
 			name: "invalid_bitvector_type",
 			input: struct {
@@ -666,7 +1194,7 @@ func TestMarshalErrors(t *testing.T) {
 			input: struct {
 				Data map[string]int
 			}{map[string]int{"a": 1}},
-			expectedErr: "maps are not supported in SSZ",
+			expectedErr: "map ssz type can only be represented by maps with unsigned integer keys",
 		},
 		{
 			name: "invalid_interface_type",
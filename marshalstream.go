@@ -0,0 +1,240 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// streamFlushSize is the amount of buffered data that triggers a write to the underlying writer.
+const streamFlushSize = 64 * 1024
+
+// streamWriter buffers encoded data and passes it to the underlying writer in chunks.
+type streamWriter struct {
+	w       io.Writer
+	buf     []byte
+	written int
+}
+
+// newStreamWriter creates a new streamWriter for the given writer.
+func newStreamWriter(w io.Writer) *streamWriter {
+	return &streamWriter{
+		w:   w,
+		buf: make([]byte, 0, streamFlushSize),
+	}
+}
+
+// flush writes all buffered data to the underlying writer.
+func (s *streamWriter) flush() error {
+	if len(s.buf) == 0 {
+		return nil
+	}
+
+	n, err := s.w.Write(s.buf)
+	s.written += n
+	if err == nil && n < len(s.buf) {
+		err = io.ErrShortWrite
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write ssz data: %w", err)
+	}
+
+	s.buf = s.buf[:0]
+	return nil
+}
+
+// flushIfFull writes the buffered data to the underlying writer once the flush size is reached.
+func (s *streamWriter) flushIfFull() error {
+	if len(s.buf) < streamFlushSize {
+		return nil
+	}
+
+	return s.flush()
+}
+
+// marshalTypeToStream is the streaming counterpart of marshalType.
+//
+// Containers, type wrappers and lists or vectors of non-byte elements are written piece by piece,
+// with the offsets of variable-size values computed upfront via getSszValueSize. All other values
+// (basic types, byte sequences, unions and types with custom marshalers) are encoded with marshalType
+// into the stream buffer, so the output is identical to the in-memory encoding.
+//
+// Parameters:
+//   - sourceType: The TypeDescriptor containing optimized metadata about the type to be encoded
+//   - sourceValue: The reflect.Value holding the data to be encoded
+//   - sw: The streamWriter receiving the encoded data
+//   - idt: Indentation level for verbose logging
+//
+// Returns:
+//   - error: An error if encoding or writing fails
+func (d *DynSsz) marshalTypeToStream(sourceType *TypeDescriptor, sourceValue reflect.Value, sw *streamWriter, idt int) error {
+	canStream := sourceType.SszCompatFlags&(SszCompatFlagFastSSZMarshaler|SszCompatFlagDynamicMarshaler) == 0
+	if canStream {
+		switch sourceType.SszType {
+		case SszTypeWrapperType, SszContainerType, SszProgressiveContainerType:
+		case SszVectorType, SszListType, SszProgressiveListType:
+			canStream = sourceType.GoTypeFlags&(GoTypeFlagIsByteArray|GoTypeFlagIsString) == 0
+		default:
+			canStream = false
+		}
+	}
+
+	if !canStream {
+		buf, err := d.marshalType(sourceType, sourceValue, sw.buf, idt)
+		if err != nil {
+			return err
+		}
+		sw.buf = buf
+
+		return sw.flushIfFull()
+	}
+
+	if sourceType.GoTypeFlags&GoTypeFlagIsPointer != 0 {
+		if sourceValue.IsNil() {
+			sourceValue = reflect.New(sourceType.Type.Elem()).Elem()
+		} else {
+			sourceValue = sourceValue.Elem()
+		}
+	}
+
+	if d.logEnabled() {
+		d.log(idt, "stream type", "type", sourceType.Type.Name(), "kind", sourceType.Kind)
+	}
+
+	switch sourceType.SszType {
+	case SszTypeWrapperType:
+		return d.marshalTypeToStream(sourceType.ElemDesc, sourceValue.Field(0), sw, idt+2)
+	case SszContainerType, SszProgressiveContainerType:
+		return d.marshalContainerToStream(sourceType, sourceValue, sw, idt)
+	default:
+		return d.marshalSequenceToStream(sourceType, sourceValue, sw, idt)
+	}
+}
+
+// marshalContainerToStream writes a container to the stream.
+//
+// The sizes of all variable-size fields are calculated first to write the offsets of the fixed section,
+// followed by the variable-size fields in order.
+func (d *DynSsz) marshalContainerToStream(sourceType *TypeDescriptor, sourceValue reflect.Value, sw *streamWriter, idt int) error {
+	fields := sourceType.ContainerDesc.Fields
+
+	fixedSize := uint32(0)
+	for _, field := range fields {
+		if field.Type.Size > 0 {
+			fixedSize += field.Type.Size
+		} else {
+			fixedSize += 4
+		}
+	}
+
+	fieldOffsets := make([]uint32, len(fields))
+	offset := fixedSize
+	for _, field := range sourceType.ContainerDesc.DynFields {
+		fieldOffsets[field.Index] = offset
+
+		size, err := d.getSszValueSize(field.Field.Type, sourceValue.Field(int(field.Index)))
+		if err != nil {
+			return wrapFieldError(err, field.Field.Name)
+		}
+		offset += size
+	}
+
+	for i, field := range fields {
+		if field.Type.Size > 0 {
+			err := d.marshalTypeToStream(field.Type, sourceValue.Field(i), sw, idt+2)
+			if err != nil {
+				return wrapFieldError(err, field.Name)
+			}
+		} else {
+			sw.buf = binary.LittleEndian.AppendUint32(sw.buf, fieldOffsets[i])
+		}
+	}
+
+	for _, field := range sourceType.ContainerDesc.DynFields {
+		err := d.marshalTypeToStream(field.Field.Type, sourceValue.Field(int(field.Index)), sw, idt+2)
+		if err != nil {
+			return wrapFieldError(err, field.Field.Name)
+		}
+	}
+
+	return nil
+}
+
+// marshalSequenceToStream writes a list or vector of non-byte elements to the stream.
+//
+// For variable-size elements, the element sizes are calculated first to write the offsets.
+// Vectors that are shorter than their defined length are padded with zero values, like in marshalVector
+// and marshalDynamicVector.
+func (d *DynSsz) marshalSequenceToStream(sourceType *TypeDescriptor, sourceValue reflect.Value, sw *streamWriter, idt int) error {
+	fieldType := sourceType.ElemDesc
+	sliceLen := sourceValue.Len()
+	isDynamic := fieldType.SszTypeFlags&SszTypeFlagIsDynamic != 0
+
+	appendZero := 0
+	if sourceType.SszType == SszVectorType && (!isDynamic || sourceType.Kind != reflect.Array) {
+		// like marshalDynamicVector, arrays of variable-size elements are not bound to the vector length
+		if err := validateVectorLength(sourceType, sliceLen); err != nil {
+			return err
+		}
+		if uint32(sliceLen) < sourceType.Len {
+			appendZero = int(sourceType.Len) - sliceLen
+		}
+	}
+
+	if err := d.validateListLimit(sourceType, sliceLen); err != nil {
+		return err
+	}
+
+	var zeroBuf []byte
+	if appendZero > 0 {
+		var zeroVal reflect.Value
+		if fieldType.GoTypeFlags&GoTypeFlagIsPointer != 0 {
+			zeroVal = reflect.New(fieldType.Type.Elem())
+		} else {
+			zeroVal = reflect.New(fieldType.Type).Elem()
+		}
+
+		var err error
+		zeroBuf, err = d.marshalType(fieldType, zeroVal, []byte{}, idt+2)
+		if err != nil {
+			return err
+		}
+	}
+
+	if isDynamic {
+		offset := uint32(4 * (sliceLen + appendZero))
+		for i := 0; i < sliceLen; i++ {
+			size, err := d.getSszValueSize(fieldType, sourceValue.Index(i))
+			if err != nil {
+				return wrapIndexError(err, i)
+			}
+
+			sw.buf = binary.LittleEndian.AppendUint32(sw.buf, offset)
+			offset += size
+		}
+		for i := 0; i < appendZero; i++ {
+			sw.buf = binary.LittleEndian.AppendUint32(sw.buf, offset)
+			offset += uint32(len(zeroBuf))
+		}
+	}
+
+	for i := 0; i < sliceLen; i++ {
+		err := d.marshalTypeToStream(fieldType, sourceValue.Index(i), sw, idt+2)
+		if err != nil {
+			return wrapIndexError(err, i)
+		}
+	}
+
+	for i := 0; i < appendZero; i++ {
+		sw.buf = append(sw.buf, zeroBuf...)
+		if err := sw.flushIfFull(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
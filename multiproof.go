@@ -0,0 +1,509 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"fmt"
+	"math/bits"
+	"reflect"
+	"sort"
+)
+
+// MultiProof is a SSZ merkle multiproof for several nodes of the same hash tree.
+//
+// The hashes are the helper nodes required to reconstruct the root from all leaves, ordered by
+// descending generalized index as defined in the consensus specs. Siblings that are shared by
+// several leaves or that can be computed from other leaves are not included.
+//
+//	proof, err := ds.GetMultiProof(state, [][]string{{"Slot"}, {"Validators", "42", "EffectiveBalance"}})
+//	root, err := ds.HashTreeRoot(state)
+//	valid := proof.Verify(root)
+type MultiProof struct {
+	Leaves  [][32]byte // The proven nodes, in the order of the requested paths
+	Indices []uint64   // The generalized indices of the leaves
+	Hashes  [][32]byte // The helper hashes, ordered by descending generalized index
+}
+
+// Verify checks whether the multiproof reconstructs the given hash tree root.
+//
+// Proofs with a leaf that is an ancestor of another leaf are rejected, as the nested leaf would not be
+// checked against the root. Duplicate indices (e.g. elements packed into the same chunk) must have
+// equal leaves.
+func (p *MultiProof) Verify(root [32]byte) bool {
+	if len(p.Indices) == 0 || len(p.Leaves) != len(p.Indices) {
+		return false
+	}
+
+	if _, _, nested := findNestedIndices(p.Indices); nested {
+		return false
+	}
+
+	helperIndices := getHelperIndices(p.Indices)
+	if len(helperIndices) != len(p.Hashes) {
+		return false
+	}
+
+	nodes := make(map[uint64][32]byte, len(p.Indices)+len(helperIndices))
+	for i, index := range p.Indices {
+		if index == 0 {
+			return false
+		}
+		if leaf, ok := nodes[index]; ok && leaf != p.Leaves[i] {
+			return false
+		}
+		nodes[index] = p.Leaves[i]
+	}
+	for i, index := range helperIndices {
+		nodes[index] = p.Hashes[i]
+	}
+
+	keys := make([]uint64, 0, len(nodes))
+	for index := range nodes {
+		keys = append(keys, index)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i] > keys[j]
+	})
+
+	// the parents are appended in descending order, like in calculate_multi_merkle_root
+	for pos := 0; pos < len(keys); pos++ {
+		index := keys[pos]
+		if index <= 1 {
+			continue
+		}
+
+		_, hasParent := nodes[index/2]
+		sibling, hasSibling := nodes[index^1]
+		if hasParent || !hasSibling {
+			continue
+		}
+
+		if index&1 == 1 {
+			nodes[index/2] = hashPair(sibling, nodes[index])
+		} else {
+			nodes[index/2] = hashPair(nodes[index], sibling)
+		}
+		keys = append(keys, index/2)
+	}
+
+	rootNode, ok := nodes[1]
+	return ok && rootNode == root
+}
+
+// multiProofPath is a requested path of a multiproof, together with the path elements that are
+// not resolved yet.
+type multiProofPath struct {
+	index int      // position of the path in the requested paths
+	path  []string // remaining path elements
+}
+
+// multiProofGroup collects the paths that descend into the same child of a composite value.
+type multiProofGroup struct {
+	step  *proofPathStep
+	elem  string
+	paths []multiProofPath
+}
+
+// GetMultiProof generates a merkle multiproof for the nodes selected by paths within the hash tree of source.
+//
+// Each path is resolved exactly like in GetProof, so the generalized indices account for the length,
+// selector and active fields mixins along the way. All paths are resolved in a single traversal: the
+// chunks of every visited value are computed once, and the helper nodes are read from the resulting
+// trees. The hashes are reduced to the minimal set of helper nodes that is needed to reconstruct the
+// root from all leaves.
+//
+// The selected nodes must not contain each other, so paths that select an ancestor of another selected
+// node are rejected. Several paths may select the same node, e.g. basic elements packed into the same chunk.
+//
+// Parameters:
+//   - source: The Go value to generate the multiproof for
+//   - paths: The field names and element indices selecting the proven nodes
+//
+// Returns:
+//   - *MultiProof: The multiproof, including the leaves, their generalized indices and the helper hashes
+//   - error: An error if no path is given, a path cannot be resolved, the paths select nested nodes
+//     or hashing fails. Path resolution errors are PathErrors, like in GetProof
+//
+// Example:
+//
+//	proof, err := ds.GetMultiProof(block, [][]string{
+//	    {"Slot"},
+//	    {"Body", "ExecutionPayload", "BlockHash"},
+//	})
+//	if err != nil {
+//	    log.Fatal("Failed to generate multiproof:", err)
+//	}
+//	fmt.Printf("leaves: %d, hashes: %d\n", len(proof.Leaves), len(proof.Hashes))
+func (d *DynSsz) GetMultiProof(source any, paths [][]string) (*MultiProof, error) {
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no paths to prove")
+	}
+
+	sourceType := reflect.TypeOf(source)
+	sourceValue := reflect.ValueOf(source)
+
+	sourceTypeDesc, err := d.typeCache.GetTypeDescriptor(sourceType, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	multiProofPaths := make([]multiProofPath, len(paths))
+	for i, path := range paths {
+		multiProofPaths[i] = multiProofPath{
+			index: i,
+			path:  path,
+		}
+	}
+
+	multiProof := &MultiProof{
+		Leaves:  make([][32]byte, len(paths)),
+		Indices: make([]uint64, len(paths)),
+	}
+
+	// the trees of all visited values, by the generalized index of their root
+	trees := map[uint64]*multiProofTree{}
+
+	err = d.getMultiProofFromType(sourceTypeDesc, sourceValue, 1, multiProofPaths, multiProof.Indices, trees)
+	if err != nil {
+		return nil, wrapRootError(err, sourceType)
+	}
+
+	if i, j, nested := findNestedIndices(multiProof.Indices); nested {
+		return nil, fmt.Errorf("path %v selects a node within the node of path %v", paths[j], paths[i])
+	}
+
+	if len(trees) == 0 {
+		// all paths prove the root itself, so no value has been descended into
+		root, err := d.getValueRoot(sourceTypeDesc, sourceValue)
+		if err != nil {
+			return nil, wrapRootError(err, sourceType)
+		}
+
+		for i := range multiProof.Leaves {
+			multiProof.Leaves[i] = root
+		}
+		multiProof.Hashes = [][32]byte{}
+		return multiProof, nil
+	}
+
+	for i, index := range multiProof.Indices {
+		leaf, ok := getMultiProofNode(trees, index)
+		if !ok {
+			return nil, fmt.Errorf("leaf node %v not found in the hash tree", index)
+		}
+		multiProof.Leaves[i] = leaf
+	}
+
+	helperIndices := getHelperIndices(multiProof.Indices)
+	multiProof.Hashes = make([][32]byte, len(helperIndices))
+	for i, index := range helperIndices {
+		hash, ok := getMultiProofNode(trees, index)
+		if !ok {
+			return nil, fmt.Errorf("helper node %v not found in the hash tree", index)
+		}
+		multiProof.Hashes[i] = hash
+	}
+
+	return multiProof, nil
+}
+
+// getMultiProofFromType resolves all paths for the value at the given generalized index.
+//
+// The chunks of the value are computed once and stored as tree in trees. Paths that select the same
+// child are grouped, so every child is descended into only once. The generalized index of each path
+// is stored in indices, once the path is resolved.
+func (d *DynSsz) getMultiProofFromType(sourceType *TypeDescriptor, sourceValue reflect.Value, gindex uint64, paths []multiProofPath, indices []uint64, trees map[uint64]*multiProofTree) error {
+	openPaths := make([]multiProofPath, 0, len(paths))
+	for _, path := range paths {
+		if len(path.path) == 0 {
+			indices[path.index] = gindex
+		} else {
+			openPaths = append(openPaths, path)
+		}
+	}
+	if len(openPaths) == 0 {
+		return nil
+	}
+
+	sourceType, sourceValue = d.unwrapProofValue(sourceType, sourceValue)
+
+	steps := make([]*proofPathStep, len(openPaths))
+	for i, path := range openPaths {
+		step, err := d.resolveProofPathStep(sourceType, path.path[0])
+		if err != nil {
+			return err
+		}
+		steps[i] = step
+	}
+
+	chunks, mixin, err := d.getMerkleChunks(sourceType, sourceValue)
+	if err != nil {
+		return err
+	}
+
+	layout, err := d.getMerkleLayout(sourceType, sourceValue)
+	if err != nil {
+		return err
+	}
+
+	trees[gindex] = newMultiProofTree(chunks, mixin, layout)
+
+	groups := []*multiProofGroup{}
+	groupIndexes := map[uint64]int{}
+	for i, path := range openPaths {
+		step := steps[i]
+		if err := checkProofPathStep(sourceType, sourceValue, step, mixin, path.path); err != nil {
+			return err
+		}
+
+		if step.childType == nil {
+			// packed basic element or absent field, the chunk is the leaf
+			chunkIndex, err := layout.getChunkGeneralizedIndex(step.chunkIndex)
+			if err != nil {
+				return err
+			}
+			indices[path.index], err = concatGeneralizedIndex(gindex, chunkIndex)
+			if err != nil {
+				return wrapProofStepError(err, sourceType, step, path.path[0])
+			}
+			continue
+		}
+
+		childPath := path
+		if step.consumed {
+			childPath.path = path.path[1:]
+		}
+
+		groupIndex, ok := groupIndexes[step.chunkIndex]
+		if !ok {
+			groupIndex = len(groups)
+			groupIndexes[step.chunkIndex] = groupIndex
+			groups = append(groups, &multiProofGroup{
+				step: step,
+				elem: path.path[0],
+			})
+		}
+		groups[groupIndex].paths = append(groups[groupIndex].paths, childPath)
+	}
+
+	for _, group := range groups {
+		childValue, err := getProofChildValue(sourceType, sourceValue, group.step)
+		if err != nil {
+			return err
+		}
+
+		chunkIndex, err := layout.getChunkGeneralizedIndex(group.step.chunkIndex)
+		if err != nil {
+			return err
+		}
+		childIndex, err := concatGeneralizedIndex(gindex, chunkIndex)
+		if err != nil {
+			return wrapProofStepError(err, sourceType, group.step, group.elem)
+		}
+
+		err = d.getMultiProofFromType(group.step.childType, childValue, childIndex, group.paths, indices, trees)
+		if err != nil {
+			return wrapProofStepError(err, sourceType, group.step, group.elem)
+		}
+	}
+
+	return nil
+}
+
+// findNestedIndices returns the positions of two generalized indices where the second one is a
+// descendant of the first one.
+func findNestedIndices(indices []uint64) (int, int, bool) {
+	positions := make(map[uint64]int, len(indices))
+	for i, index := range indices {
+		if _, ok := positions[index]; !ok {
+			positions[index] = i
+		}
+	}
+
+	for i, index := range indices {
+		for parent := index >> 1; parent > 0; parent >>= 1 {
+			if j, ok := positions[parent]; ok {
+				return j, i, true
+			}
+		}
+	}
+
+	return 0, 0, false
+}
+
+// getMultiProofNode returns a node of the hash tree from the tree of the innermost visited value that contains it.
+func getMultiProofNode(trees map[uint64]*multiProofTree, index uint64) ([32]byte, bool) {
+	for root := index; root > 0; root >>= 1 {
+		tree, ok := trees[root]
+		if !ok {
+			continue
+		}
+
+		depth := bits.Len64(index) - bits.Len64(root)
+		return tree.getNode(uint64(1)<<depth | index&(uint64(1)<<depth-1))
+	}
+
+	return [32]byte{}, false
+}
+
+// multiProofTree holds all nodes of the hash tree of a composite value, so that any node can be
+// read without merkleizing the chunks again.
+type multiProofTree struct {
+	layout   *merkleLayout
+	mixin    [32]byte
+	layers   [][][32]byte   // layers of a binary tree, from the chunks up to the content root
+	subtrees [][][][32]byte // layers of the binary subtrees of a progressive tree
+	spine    [][32]byte     // roots of the progressive subtrees, spine[0] is the content root
+}
+
+// newMultiProofTree builds the tree of a composite value from its chunks.
+func newMultiProofTree(chunks [][32]byte, mixin [32]byte, layout *merkleLayout) *multiProofTree {
+	tree := &multiProofTree{
+		layout: layout,
+		mixin:  mixin,
+	}
+
+	if !layout.progressive {
+		tree.layers = buildChunkLayers(chunks, getTreeDepth(layout.limit))
+		return tree
+	}
+
+	// the binary subtrees hold 1, 4, 16, ... chunks
+	for depth := 0; len(chunks) > 0; depth += 2 {
+		size := 1 << depth
+		if size > len(chunks) {
+			size = len(chunks)
+		}
+		tree.subtrees = append(tree.subtrees, buildChunkLayers(chunks[:size], depth))
+		chunks = chunks[size:]
+	}
+
+	tree.spine = make([][32]byte, len(tree.subtrees)+1)
+	for i := len(tree.subtrees) - 1; i >= 0; i-- {
+		subtree := tree.subtrees[i]
+		tree.spine[i] = hashPair(tree.spine[i+1], subtree[len(subtree)-1][0])
+	}
+
+	return tree
+}
+
+// getNode returns the node with the given generalized index relative to the root of the value.
+func (t *multiProofTree) getNode(index uint64) ([32]byte, bool) {
+	if !t.layout.mixin {
+		return t.getContentNode(index)
+	}
+
+	depth := bits.Len64(index) - 1
+	switch {
+	case depth == 0:
+		content, _ := t.getContentNode(1)
+		return hashPair(content, t.mixin), true
+	case index>>(depth-1) == 3:
+		if depth > 1 {
+			return [32]byte{}, false
+		}
+		return t.mixin, true
+	default:
+		// strip the content root from the index
+		return t.getContentNode(uint64(1)<<(depth-1) | index&(uint64(1)<<(depth-1)-1))
+	}
+}
+
+// getContentNode returns the node with the given generalized index relative to the content root.
+func (t *multiProofTree) getContentNode(index uint64) ([32]byte, bool) {
+	if !t.layout.progressive {
+		return getChunkLayerNode(t.layers, index)
+	}
+
+	// descend along the left spine until the index turns into a binary subtree
+	subtree := 0
+	for depth := bits.Len64(index) - 2; depth >= 0; depth-- {
+		if index>>depth&1 == 0 {
+			subtree++
+			continue
+		}
+
+		subIndex := uint64(1)<<depth | index&(uint64(1)<<depth-1)
+		if subtree >= len(t.subtrees) {
+			// empty subtree
+			level := 2*subtree - depth
+			if level < 0 {
+				return [32]byte{}, false
+			}
+			return getZeroHash(level), true
+		}
+		return getChunkLayerNode(t.subtrees[subtree], subIndex)
+	}
+
+	if subtree >= len(t.spine) {
+		return [32]byte{}, true
+	}
+	return t.spine[subtree], true
+}
+
+// buildChunkLayers computes all layers of a binary merkle tree of the given depth.
+// Missing nodes are zero hashes, they are not stored in the layers.
+func buildChunkLayers(chunks [][32]byte, depth int) [][][32]byte {
+	layers := make([][][32]byte, depth+1)
+	layers[0] = chunks
+
+	for level := 0; level < depth; level++ {
+		layer := layers[level]
+		next := make([][32]byte, (len(layer)+1)/2)
+		for i := range next {
+			right := getZeroHash(level)
+			if 2*i+1 < len(layer) {
+				right = layer[2*i+1]
+			}
+			next[i] = hashPair(layer[2*i], right)
+		}
+		layers[level+1] = next
+	}
+
+	return layers
+}
+
+// getChunkLayerNode returns the node with the given generalized index from the layers of a binary merkle tree.
+func getChunkLayerNode(layers [][][32]byte, index uint64) ([32]byte, bool) {
+	depth := bits.Len64(index) - 1
+	if depth >= len(layers) {
+		return [32]byte{}, false
+	}
+
+	level := len(layers) - 1 - depth
+	position := index ^ (uint64(1) << depth)
+	if position < uint64(len(layers[level])) {
+		return layers[level][position], true
+	}
+
+	return getZeroHash(level), true
+}
+
+// getHelperIndices returns the generalized indices of the nodes that are required to verify a multiproof
+// for the given leaves, ordered by descending generalized index (get_helper_indices in the consensus specs).
+//
+// These are the siblings of all nodes on the paths from the leaves to the root, except for nodes that are
+// on one of the paths themselves, as they are computed from the leaves.
+func getHelperIndices(indices []uint64) []uint64 {
+	branchIndices := map[uint64]bool{}
+	pathIndices := map[uint64]bool{}
+	for _, index := range indices {
+		for ; index > 1; index >>= 1 {
+			branchIndices[index^1] = true
+			pathIndices[index] = true
+		}
+	}
+
+	helperIndices := make([]uint64, 0, len(branchIndices))
+	for index := range branchIndices {
+		if !pathIndices[index] {
+			helperIndices = append(helperIndices, index)
+		}
+	}
+	sort.Slice(helperIndices, func(i, j int) bool {
+		return helperIndices[i] > helperIndices[j]
+	})
+
+	return helperIndices
+}
@@ -0,0 +1,264 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"errors"
+	"sort"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+func TestGetMultiProof(t *testing.T) {
+	payload := &proofTestContainer{
+		Slot:     1337,
+		Root:     [32]byte{1, 2, 3},
+		Balances: []uint64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+		Elements: []*proofTestElement{
+			{Index: 1, Data: []byte{1, 2, 3}},
+			{Index: 2},
+			nil,
+			{Index: 4, Data: []byte{4}},
+		},
+		Bits:        []byte{0xaa, 0x55, 0x03},
+		Progressive: []uint32{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22},
+		Container: proofTestProgressive{
+			Field0: 42,
+			Field1: []uint16{1, 2, 3},
+		},
+		Optional: &proofTestElement{Index: 3},
+		Stable: proofTestStable{
+			B: &proofTestElement{Index: 12, Data: []byte{12}},
+		},
+		Map: map[uint64]*proofTestElement{
+			7: {Index: 7},
+		},
+	}
+	payload.Union.Variant = 0
+	payload.Union.Data = proofTestElement{Index: 9, Data: []byte{9}}
+	payload.Maybe.Selector = 1
+	payload.Maybe.Data = proofTestElement{Index: 10, Data: []byte{10}}
+
+	testCases := []struct {
+		name  string
+		paths [][]string
+	}{
+		{"single", [][]string{{"Slot"}}},
+		{"siblings", [][]string{{"Slot"}, {"Root"}}},
+		{"list_elements", [][]string{{"Balances", "0"}, {"Balances", "9"}, {"Elements", "0", "Index"}, {"Elements", "3", "Data"}}},
+		{"nested", [][]string{{"Elements", "3", "Index"}, {"Container", "Field1", "2"}, {"Progressive", "21"}, {"Stable", "B", "Data"}}},
+		{"unions", [][]string{{"Union", "Data"}, {"Optional", "Index"}, {"Maybe", "Index"}, {"Map", "0", "Value", "Index"}}},
+		{"progressive", [][]string{{"Progressive", "0"}, {"Progressive", "5"}, {"Progressive", "21"}, {"Container", "Field0"}}},
+		{"shared_branch", [][]string{{"Elements", "1", "Data"}, {"Elements", "1", "Index"}, {"Elements", "0"}, {"Bits", "3"}}},
+		{"root", [][]string{{}}},
+		{"root_twice", [][]string{{}, {}}},
+	}
+
+	ds := NewDynSsz(nil)
+
+	root, err := ds.HashTreeRoot(payload)
+	if err != nil {
+		t.Fatalf("failed to hash payload: %v", err)
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			proof, err := ds.GetMultiProof(payload, tc.paths)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			singleHashes := 0
+			for i, path := range tc.paths {
+				singleProof, err := ds.GetProof(payload, path)
+				if err != nil {
+					t.Fatalf("path %v: unexpected error: %v", path, err)
+				}
+				singleHashes += len(singleProof.Hashes)
+
+				if proof.Indices[i] != singleProof.GeneralizedIndex {
+					t.Errorf("path %v: generalized index mismatch, expected %v, got %v", path, singleProof.GeneralizedIndex, proof.Indices[i])
+				}
+				if proof.Leaves[i] != singleProof.Leaf {
+					t.Errorf("path %v: leaf mismatch, expected 0x%x, got 0x%x", path, singleProof.Leaf, proof.Leaves[i])
+				}
+			}
+
+			if len(tc.paths) > 1 && singleHashes > 0 && len(proof.Hashes) >= singleHashes {
+				t.Errorf("expected less hashes than the single proofs (%v), got %v", singleHashes, len(proof.Hashes))
+			}
+
+			if !proof.Verify(root) {
+				t.Errorf("multiproof does not verify against the hash tree root")
+			}
+
+			// a modified leaf must not verify
+			proof.Leaves[0][31] ^= 0xff
+			if proof.Verify(root) {
+				t.Errorf("multiproof with modified leaf verifies against the hash tree root")
+			}
+		})
+	}
+}
+
+func TestGetMultiProofHelperOrder(t *testing.T) {
+	payload := struct {
+		A uint64
+		B uint64
+		C uint64
+		D uint64
+	}{1, 2, 3, 4}
+
+	ds := NewDynSsz(nil)
+
+	root, err := ds.HashTreeRoot(payload)
+	if err != nil {
+		t.Fatalf("failed to hash payload: %v", err)
+	}
+
+	// leaves 4 & 7 require the helpers 6 (C) and 5 (B), in descending order
+	proof, err := ds.GetMultiProof(payload, [][]string{{"A"}, {"D"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(proof.Indices) != 2 || proof.Indices[0] != 4 || proof.Indices[1] != 7 {
+		t.Errorf("unexpected indices: %v", proof.Indices)
+	}
+	if len(proof.Hashes) != 2 || proof.Hashes[0] != [32]byte{3} || proof.Hashes[1] != [32]byte{2} {
+		t.Errorf("unexpected helper hashes: %x", proof.Hashes)
+	}
+	if !proof.Verify(root) {
+		t.Errorf("multiproof does not verify against the hash tree root")
+	}
+
+	// leaves 4 & 5 share the helper 3
+	proof, err = ds.GetMultiProof(payload, [][]string{{"B"}, {"A"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(proof.Hashes) != 1 || !proof.Verify(root) {
+		t.Errorf("expected a single helper hash, got %v", len(proof.Hashes))
+	}
+
+	// malformed proofs are rejected
+	proof.Hashes = append(proof.Hashes, [32]byte{})
+	if proof.Verify(root) {
+		t.Errorf("multiproof with additional hash verifies against the hash tree root")
+	}
+	if (&MultiProof{}).Verify(root) {
+		t.Errorf("empty multiproof verifies against the hash tree root")
+	}
+}
+
+func TestGetMultiProofErrors(t *testing.T) {
+	payload := &proofTestContainer{
+		Bits: []byte{0x03},
+	}
+	payload.Union.Data = proofTestElement{}
+	payload.Maybe.Selector = 0
+
+	testCases := []struct {
+		name        string
+		paths       [][]string
+		expectedErr string
+	}{
+		{"no_paths", [][]string{}, "no paths to prove"},
+		{"unknown_field", [][]string{{"Slot"}, {"Missing"}}, "unknown field Missing"},
+		{"index_out_of_range", [][]string{{"Slot"}, {"Balances", "0"}}, "proofTestContainer.Balances: index 0 out of range (length: 0)"},
+		{"none_union", [][]string{{"Maybe", "Index"}}, "proofTestContainer.Maybe: cannot descend into None union value"},
+		{"basic_type", [][]string{{"Slot", "0"}}, "proofTestContainer.Slot: cannot descend into basic type uint64"},
+		{"nested", [][]string{{"Union"}, {"Union", "Index"}}, "path [Union Index] selects a node within the node of path [Union]"},
+		{"root_and_field", [][]string{{"Slot"}, {}}, "path [Slot] selects a node within the node of path []"},
+	}
+
+	ds := NewDynSsz(nil)
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ds.GetMultiProof(payload, tc.paths)
+			if err == nil || !contains(err.Error(), tc.expectedErr) {
+				t.Errorf("expected error containing %q, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+
+	// path resolution errors are reported as PathError, like in GetProof
+	_, err := ds.GetMultiProof(payload, [][]string{{"Slot"}, {"Balances", "0"}})
+	var pathErr *PathError
+	if !errors.As(err, &pathErr) || pathErr.Path != "proofTestContainer.Balances" {
+		t.Errorf("expected PathError for proofTestContainer.Balances, got %v", err)
+	}
+}
+
+func TestMultiProofVerifyNested(t *testing.T) {
+	payload := &proofTestContainer{
+		Elements: []*proofTestElement{
+			{Index: 1, Data: []byte{1, 2, 3}},
+		},
+		Bits: []byte{0x03},
+	}
+	payload.Union.Data = proofTestElement{}
+
+	ds := NewDynSsz(nil)
+
+	root, err := ds.HashTreeRoot(payload)
+	if err != nil {
+		t.Fatalf("failed to hash payload: %v", err)
+	}
+
+	// assemble a multiproof for a node and one of its descendants from the single proofs
+	proof := &MultiProof{}
+	nodes := map[uint64][32]byte{}
+	pathIndices := map[uint64]bool{}
+	for _, path := range [][]string{{"Elements"}, {"Elements", "0"}} {
+		singleProof, err := ds.GetProof(payload, path)
+		if err != nil {
+			t.Fatalf("path %v: unexpected error: %v", path, err)
+		}
+
+		proof.Leaves = append(proof.Leaves, singleProof.Leaf)
+		proof.Indices = append(proof.Indices, singleProof.GeneralizedIndex)
+
+		index := singleProof.GeneralizedIndex
+		for _, sibling := range singleProof.Hashes {
+			nodes[index^1] = sibling
+			pathIndices[index] = true
+			index >>= 1
+		}
+	}
+
+	helperIndices := []uint64{}
+	for index := range nodes {
+		if !pathIndices[index] {
+			helperIndices = append(helperIndices, index)
+		}
+	}
+	sort.Slice(helperIndices, func(i, j int) bool {
+		return helperIndices[i] > helperIndices[j]
+	})
+	for _, index := range helperIndices {
+		proof.Hashes = append(proof.Hashes, nodes[index])
+	}
+
+	// the descendant leaf is never checked against the ancestor, so nested proofs must be rejected
+	proof.Leaves[1][31] ^= 0xff
+	if proof.Verify(root) {
+		t.Errorf("multiproof with tampered descendant leaf verifies against the hash tree root")
+	}
+
+	// duplicate indices must have equal leaves
+	slotProof, err := ds.GetMultiProof(payload, [][]string{{"Slot"}, {"Root"}, {"Slot"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slotProof.Verify(root) {
+		t.Errorf("multiproof with duplicate path does not verify against the hash tree root")
+	}
+	slotProof.Leaves[2][31] ^= 0xff
+	if slotProof.Verify(root) {
+		t.Errorf("multiproof with tampered duplicate leaf verifies against the hash tree root")
+	}
+}
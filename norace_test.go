@@ -0,0 +1,9 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+
+//go:build !race
+
+package dynssz_test
+
+const raceEnabled = false
@@ -0,0 +1,172 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+// Well-known preset names that can be passed to NewDynSszWithPreset.
+const (
+	PresetMainnet = "mainnet"
+	PresetMinimal = "minimal"
+)
+
+// presets contains the constants of the ethereum consensus presets (phase0 up to electra).
+var presets = map[string]map[string]uint64{
+	PresetMainnet: {
+		// Phase0
+		"MAX_COMMITTEES_PER_SLOT":          64,
+		"TARGET_COMMITTEE_SIZE":            128,
+		"MAX_VALIDATORS_PER_COMMITTEE":     2048,
+		"SHUFFLE_ROUND_COUNT":              90,
+		"HYSTERESIS_QUOTIENT":              4,
+		"HYSTERESIS_DOWNWARD_MULTIPLIER":   1,
+		"HYSTERESIS_UPWARD_MULTIPLIER":     5,
+		"MIN_DEPOSIT_AMOUNT":               1000000000,
+		"MAX_EFFECTIVE_BALANCE":            32000000000,
+		"EFFECTIVE_BALANCE_INCREMENT":      1000000000,
+		"MIN_ATTESTATION_INCLUSION_DELAY":  1,
+		"SLOTS_PER_EPOCH":                  32,
+		"MIN_SEED_LOOKAHEAD":               1,
+		"MAX_SEED_LOOKAHEAD":               4,
+		"EPOCHS_PER_ETH1_VOTING_PERIOD":    64,
+		"SLOTS_PER_HISTORICAL_ROOT":        8192,
+		"MIN_EPOCHS_TO_INACTIVITY_PENALTY": 4,
+		"EPOCHS_PER_HISTORICAL_VECTOR":     65536,
+		"EPOCHS_PER_SLASHINGS_VECTOR":      8192,
+		"HISTORICAL_ROOTS_LIMIT":           16777216,
+		"VALIDATOR_REGISTRY_LIMIT":         1099511627776,
+		"BASE_REWARD_FACTOR":               64,
+		"WHISTLEBLOWER_REWARD_QUOTIENT":    512,
+		"PROPOSER_REWARD_QUOTIENT":         8,
+		"INACTIVITY_PENALTY_QUOTIENT":      67108864,
+		"MIN_SLASHING_PENALTY_QUOTIENT":    128,
+		"PROPORTIONAL_SLASHING_MULTIPLIER": 1,
+		"MAX_PROPOSER_SLASHINGS":           16,
+		"MAX_ATTESTER_SLASHINGS":           2,
+		"MAX_ATTESTATIONS":                 128,
+		"MAX_DEPOSITS":                     16,
+		"MAX_VOLUNTARY_EXITS":              16,
+
+		// Altair
+		"INACTIVITY_PENALTY_QUOTIENT_ALTAIR":      50331648,
+		"MIN_SLASHING_PENALTY_QUOTIENT_ALTAIR":    64,
+		"PROPORTIONAL_SLASHING_MULTIPLIER_ALTAIR": 2,
+		"SYNC_COMMITTEE_SIZE":                     512,
+		"EPOCHS_PER_SYNC_COMMITTEE_PERIOD":        256,
+		"MIN_SYNC_COMMITTEE_PARTICIPANTS":         1,
+		"UPDATE_TIMEOUT":                          8192,
+
+		// Bellatrix
+		"INACTIVITY_PENALTY_QUOTIENT_BELLATRIX":      16777216,
+		"MIN_SLASHING_PENALTY_QUOTIENT_BELLATRIX":    32,
+		"PROPORTIONAL_SLASHING_MULTIPLIER_BELLATRIX": 3,
+		"MAX_BYTES_PER_TRANSACTION":                  1073741824,
+		"MAX_TRANSACTIONS_PER_PAYLOAD":               1048576,
+		"BYTES_PER_LOGS_BLOOM":                       256,
+		"MAX_EXTRA_DATA_BYTES":                       32,
+
+		// Capella
+		"MAX_BLS_TO_EXECUTION_CHANGES":         16,
+		"MAX_WITHDRAWALS_PER_PAYLOAD":          16,
+		"MAX_VALIDATORS_PER_WITHDRAWALS_SWEEP": 16384,
+
+		// Deneb
+		"MAX_BLOB_COMMITMENTS_PER_BLOCK":       4096,
+		"KZG_COMMITMENT_INCLUSION_PROOF_DEPTH": 17,
+		"FIELD_ELEMENTS_PER_BLOB":              4096,
+
+		// Electra
+		"MIN_ACTIVATION_BALANCE":                     32000000000,
+		"MAX_EFFECTIVE_BALANCE_ELECTRA":              2048000000000,
+		"MIN_SLASHING_PENALTY_QUOTIENT_ELECTRA":      4096,
+		"WHISTLEBLOWER_REWARD_QUOTIENT_ELECTRA":      4096,
+		"PENDING_DEPOSITS_LIMIT":                     134217728,
+		"PENDING_PARTIAL_WITHDRAWALS_LIMIT":          134217728,
+		"PENDING_CONSOLIDATIONS_LIMIT":               262144,
+		"MAX_ATTESTER_SLASHINGS_ELECTRA":             1,
+		"MAX_ATTESTATIONS_ELECTRA":                   8,
+		"MAX_DEPOSIT_REQUESTS_PER_PAYLOAD":           8192,
+		"MAX_WITHDRAWAL_REQUESTS_PER_PAYLOAD":        16,
+		"MAX_CONSOLIDATION_REQUESTS_PER_PAYLOAD":     2,
+		"MAX_PENDING_PARTIALS_PER_WITHDRAWALS_SWEEP": 8,
+		"MAX_PENDING_DEPOSITS_PER_EPOCH":             16,
+	},
+	PresetMinimal: {
+		// Phase0
+		"MAX_COMMITTEES_PER_SLOT":          4,
+		"TARGET_COMMITTEE_SIZE":            4,
+		"MAX_VALIDATORS_PER_COMMITTEE":     2048,
+		"SHUFFLE_ROUND_COUNT":              10,
+		"HYSTERESIS_QUOTIENT":              4,
+		"HYSTERESIS_DOWNWARD_MULTIPLIER":   1,
+		"HYSTERESIS_UPWARD_MULTIPLIER":     5,
+		"MIN_DEPOSIT_AMOUNT":               1000000000,
+		"MAX_EFFECTIVE_BALANCE":            32000000000,
+		"EFFECTIVE_BALANCE_INCREMENT":      1000000000,
+		"MIN_ATTESTATION_INCLUSION_DELAY":  1,
+		"SLOTS_PER_EPOCH":                  8,
+		"MIN_SEED_LOOKAHEAD":               1,
+		"MAX_SEED_LOOKAHEAD":               4,
+		"EPOCHS_PER_ETH1_VOTING_PERIOD":    4,
+		"SLOTS_PER_HISTORICAL_ROOT":        64,
+		"MIN_EPOCHS_TO_INACTIVITY_PENALTY": 4,
+		"EPOCHS_PER_HISTORICAL_VECTOR":     64,
+		"EPOCHS_PER_SLASHINGS_VECTOR":      64,
+		"HISTORICAL_ROOTS_LIMIT":           16777216,
+		"VALIDATOR_REGISTRY_LIMIT":         1099511627776,
+		"BASE_REWARD_FACTOR":               64,
+		"WHISTLEBLOWER_REWARD_QUOTIENT":    512,
+		"PROPOSER_REWARD_QUOTIENT":         8,
+		"INACTIVITY_PENALTY_QUOTIENT":      33554432,
+		"MIN_SLASHING_PENALTY_QUOTIENT":    64,
+		"PROPORTIONAL_SLASHING_MULTIPLIER": 2,
+		"MAX_PROPOSER_SLASHINGS":           16,
+		"MAX_ATTESTER_SLASHINGS":           2,
+		"MAX_ATTESTATIONS":                 128,
+		"MAX_DEPOSITS":                     16,
+		"MAX_VOLUNTARY_EXITS":              16,
+
+		// Altair
+		"INACTIVITY_PENALTY_QUOTIENT_ALTAIR":      50331648,
+		"MIN_SLASHING_PENALTY_QUOTIENT_ALTAIR":    64,
+		"PROPORTIONAL_SLASHING_MULTIPLIER_ALTAIR": 2,
+		"SYNC_COMMITTEE_SIZE":                     32,
+		"EPOCHS_PER_SYNC_COMMITTEE_PERIOD":        8,
+		"MIN_SYNC_COMMITTEE_PARTICIPANTS":         1,
+		"UPDATE_TIMEOUT":                          64,
+
+		// Bellatrix
+		"INACTIVITY_PENALTY_QUOTIENT_BELLATRIX":      16777216,
+		"MIN_SLASHING_PENALTY_QUOTIENT_BELLATRIX":    32,
+		"PROPORTIONAL_SLASHING_MULTIPLIER_BELLATRIX": 3,
+		"MAX_BYTES_PER_TRANSACTION":                  1073741824,
+		"MAX_TRANSACTIONS_PER_PAYLOAD":               1048576,
+		"BYTES_PER_LOGS_BLOOM":                       256,
+		"MAX_EXTRA_DATA_BYTES":                       32,
+
+		// Capella
+		"MAX_BLS_TO_EXECUTION_CHANGES":         16,
+		"MAX_WITHDRAWALS_PER_PAYLOAD":          4,
+		"MAX_VALIDATORS_PER_WITHDRAWALS_SWEEP": 16,
+
+		// Deneb
+		"MAX_BLOB_COMMITMENTS_PER_BLOCK":       32,
+		"KZG_COMMITMENT_INCLUSION_PROOF_DEPTH": 10,
+		"FIELD_ELEMENTS_PER_BLOB":              4096,
+
+		// Electra
+		"MIN_ACTIVATION_BALANCE":                     32000000000,
+		"MAX_EFFECTIVE_BALANCE_ELECTRA":              2048000000000,
+		"MIN_SLASHING_PENALTY_QUOTIENT_ELECTRA":      4096,
+		"WHISTLEBLOWER_REWARD_QUOTIENT_ELECTRA":      4096,
+		"PENDING_DEPOSITS_LIMIT":                     134217728,
+		"PENDING_PARTIAL_WITHDRAWALS_LIMIT":          64,
+		"PENDING_CONSOLIDATIONS_LIMIT":               64,
+		"MAX_ATTESTER_SLASHINGS_ELECTRA":             1,
+		"MAX_ATTESTATIONS_ELECTRA":                   8,
+		"MAX_DEPOSIT_REQUESTS_PER_PAYLOAD":           4,
+		"MAX_WITHDRAWAL_REQUESTS_PER_PAYLOAD":        2,
+		"MAX_CONSOLIDATION_REQUESTS_PER_PAYLOAD":     2,
+		"MAX_PENDING_PARTIALS_PER_WITHDRAWALS_SWEEP": 2,
+		"MAX_PENDING_DEPOSITS_PER_EPOCH":             16,
+	},
+}
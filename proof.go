@@ -0,0 +1,966 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"reflect"
+	"strconv"
+	"sync"
+
+	"github.com/pk910/dynamic-ssz/hasher"
+	"github.com/pk910/dynamic-ssz/sszutils"
+)
+
+// Proof is a SSZ merkle proof for a single node of a hash tree.
+//
+// The proof can be verified against the hash tree root of the object it was generated from:
+//
+//	proof, err := ds.GetProof(state, []string{"Validators", "42", "EffectiveBalance"})
+//	root, err := ds.HashTreeRoot(state)
+//	valid := proof.Verify(root)
+type Proof struct {
+	Leaf             [32]byte   // The proven node (a field root or a packed chunk)
+	Hashes           [][32]byte // The sibling hashes, ordered from the leaf up to the root
+	GeneralizedIndex uint64     // The generalized index of the leaf in the hash tree
+}
+
+// Verify checks whether the proof reconstructs the given hash tree root.
+func (p *Proof) Verify(root [32]byte) bool {
+	if p.GeneralizedIndex == 0 || bits.Len64(p.GeneralizedIndex)-1 != len(p.Hashes) {
+		return false
+	}
+
+	node := p.Leaf
+	index := p.GeneralizedIndex
+	for _, sibling := range p.Hashes {
+		if index&1 == 1 {
+			node = hashPair(sibling, node)
+		} else {
+			node = hashPair(node, sibling)
+		}
+		index >>= 1
+	}
+
+	return node == root
+}
+
+// merkleLayout describes how the hash tree root of a composite value is assembled from its chunks.
+type merkleLayout struct {
+	limit       uint64 // number of chunks the binary tree is padded to (unused for progressive trees)
+	progressive bool   // whether the chunks are merkleized progressively (EIP-7916)
+	mixin       bool   // whether the content root is mixed with an additional chunk (length, selector or active fields)
+}
+
+// proofPathStep describes how a single path element descends into the hash tree of a composite type.
+type proofPathStep struct {
+	chunkIndex uint64          // index of the chunk containing the selected child
+	fieldIndex int             // struct field index of the child (containers)
+	elemIndex  int             // element index of the child (vectors & lists)
+	childType  *TypeDescriptor // type of the child, nil if the child is packed into a chunk
+	consumed   bool            // whether the path element has been consumed by this step
+}
+
+// GetProof generates a merkle proof for the node selected by path within the hash tree of source.
+//
+// The path is a sequence of struct field names and element indices (as decimal strings) that
+// selects a node in the hash tree. An empty path selects the root of source itself.
+// When the path selects an element of a list or vector of basic types, the proof is generated
+// for the 32 byte chunk that contains the packed element.
+//
+// The generalized index accounts for the length mixin of lists & bitlists, the selector mixin of
+// unions and the active fields mixin of progressive containers, exactly like the hashing path does.
+//
+// Parameters:
+//   - source: The Go value to generate the proof for
+//   - path: The field names and element indices selecting the proven node
+//
+// Returns:
+//   - *Proof: The merkle proof, including the leaf, sibling hashes and generalized index
+//   - error: An error if the path cannot be resolved, its generalized index exceeds 64 bits (sszutils.ErrGindexOverflow) or hashing fails
+//
+// Example:
+//
+//	proof, err := ds.GetProof(block, []string{"Body", "ExecutionPayload", "BlockHash"})
+//	if err != nil {
+//	    log.Fatal("Failed to generate proof:", err)
+//	}
+//	fmt.Printf("gindex: %d, branch length: %d\n", proof.GeneralizedIndex, len(proof.Hashes))
+func (d *DynSsz) GetProof(source any, path []string) (*Proof, error) {
+	sourceType := reflect.TypeOf(source)
+	sourceValue := reflect.ValueOf(source)
+
+	sourceTypeDesc, err := d.typeCache.GetTypeDescriptor(sourceType, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	proof, err := d.getProofFromType(sourceTypeDesc, sourceValue, path)
+	if err != nil {
+		return nil, wrapRootError(err, sourceType)
+	}
+
+	return proof, nil
+}
+
+// GetGeneralizedIndex returns the generalized index of the node selected by path within the hash tree of type t.
+//
+// The path is resolved exactly like in GetProof, but only the type information is used, so no value
+// and no hashing is required. This allows precomputing generalized indices once and reusing them.
+//
+// As the tree layout must be known from the type alone, paths can not descend through unions (the
+// layout depends on the selected variant) or lists and bitlists without ssz-max limit (the tree depth
+// depends on the actual length).
+//
+// Parameters:
+//   - t: The reflect.Type of the root value
+//   - path: The field names and element indices selecting the node
+//
+// Returns:
+//   - uint64: The generalized index of the selected node
+//   - error: An error if the path cannot be resolved against the type or its generalized index exceeds 64 bits
+//
+// Example:
+//
+//	gindex, err := ds.GetGeneralizedIndex(reflect.TypeOf(phase0.BeaconState{}), []string{"Validators", "0"})
+//	if err != nil {
+//	    log.Fatal("Failed to resolve path:", err)
+//	}
+func (d *DynSsz) GetGeneralizedIndex(t reflect.Type, path []string) (uint64, error) {
+	sourceTypeDesc, err := d.typeCache.GetTypeDescriptor(t, nil, nil, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	gindex := uint64(1)
+	for i := 0; i < len(path); {
+		for sourceTypeDesc.SszType == SszTypeWrapperType || sourceTypeDesc.SszType == SszMapType {
+			sourceTypeDesc = sourceTypeDesc.ElemDesc
+		}
+
+		if sourceTypeDesc.SszType == SszCompatibleUnionType || sourceTypeDesc.SszType == SszUnionType {
+			return 0, fmt.Errorf("cannot resolve path %v through union type %v without a value", path[i], sourceTypeDesc.Type)
+		}
+
+		step, err := d.resolveProofPathStep(sourceTypeDesc, path[i])
+		if err != nil {
+			return 0, err
+		}
+
+		layout, err := d.getMerkleLayout(sourceTypeDesc, reflect.Value{})
+		if err != nil {
+			return 0, err
+		}
+
+		chunkIndex, err := layout.getChunkGeneralizedIndex(step.chunkIndex)
+		if err != nil {
+			return 0, err
+		}
+		gindex, err = concatGeneralizedIndex(gindex, chunkIndex)
+		if err != nil {
+			return 0, err
+		}
+
+		if step.consumed {
+			i++
+		}
+
+		if step.childType == nil {
+			// packed basic element, the chunk is the leaf
+			if i < len(path) {
+				return 0, fmt.Errorf("cannot descend into basic element %v", path[i-1])
+			}
+			break
+		}
+
+		sourceTypeDesc = step.childType
+	}
+
+	return gindex, nil
+}
+
+// getProofFromType recursively resolves the path and builds the proof for the selected node.
+//
+// Each level computes the chunks of the current composite value, collects the sibling hashes of
+// the selected chunk and descends into the selected child. The sibling hashes of the inner levels
+// come first, so the resulting branch is ordered from the leaf up to the root.
+func (d *DynSsz) getProofFromType(sourceType *TypeDescriptor, sourceValue reflect.Value, path []string) (*Proof, error) {
+	if len(path) == 0 {
+		root, err := d.getValueRoot(sourceType, sourceValue)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Proof{
+			Leaf:             root,
+			GeneralizedIndex: 1,
+		}, nil
+	}
+
+	sourceType, sourceValue = d.unwrapProofValue(sourceType, sourceValue)
+
+	step, err := d.resolveProofPathStep(sourceType, path[0])
+	if err != nil {
+		return nil, err
+	}
+
+	chunks, mixin, err := d.getMerkleChunks(sourceType, sourceValue)
+	if err != nil {
+		return nil, err
+	}
+
+	layout, err := d.getMerkleLayout(sourceType, sourceValue)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkProofPathStep(sourceType, sourceValue, step, mixin, path); err != nil {
+		return nil, err
+	}
+
+	gindex, err := layout.getChunkGeneralizedIndex(step.chunkIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	var hashes [][32]byte
+	if layout.progressive {
+		hashes = proveProgressiveChunks(chunks, step.chunkIndex)
+	} else {
+		hashes = proveChunks(chunks, getTreeDepth(layout.limit), step.chunkIndex)
+	}
+	if layout.mixin {
+		hashes = append(hashes, mixin)
+	}
+
+	if step.childType == nil {
+		// packed basic element or absent field, the chunk is the leaf
+		var leaf [32]byte
+		if step.chunkIndex < uint64(len(chunks)) {
+			leaf = chunks[step.chunkIndex]
+		}
+
+		return &Proof{
+			Leaf:             leaf,
+			Hashes:           hashes,
+			GeneralizedIndex: gindex,
+		}, nil
+	}
+
+	childValue, err := getProofChildValue(sourceType, sourceValue, step)
+	if err != nil {
+		return nil, err
+	}
+
+	childPath := path
+	if step.consumed {
+		childPath = path[1:]
+	}
+
+	childProof, err := d.getProofFromType(step.childType, childValue, childPath)
+	if err != nil {
+		return nil, wrapProofStepError(err, sourceType, step, path[0])
+	}
+
+	childProof.Hashes = append(childProof.Hashes, hashes...)
+	childProof.GeneralizedIndex, err = concatGeneralizedIndex(gindex, childProof.GeneralizedIndex)
+	if err != nil {
+		return nil, wrapProofStepError(err, sourceType, step, path[0])
+	}
+
+	return childProof, nil
+}
+
+// checkProofPathStep validates a resolved path step against the current value.
+// Absent fields of stable containers are turned into leafs, as they are represented by a zero chunk.
+func checkProofPathStep(sourceType *TypeDescriptor, sourceValue reflect.Value, step *proofPathStep, mixin [32]byte, path []string) error {
+	switch sourceType.SszType {
+	case SszListType, SszBitlistType, SszProgressiveListType, SszProgressiveBitlistType:
+		// the mixin carries the actual number of elements / bits
+		length := binary.LittleEndian.Uint64(mixin[:8])
+		if uint64(step.elemIndex) >= length {
+			return fmt.Errorf("index %v out of range (length: %v)", step.elemIndex, length)
+		}
+	}
+
+	if sourceType.SszType == SszStableContainerType && sourceValue.Field(step.fieldIndex).IsNil() {
+		if len(path) > 1 {
+			return fmt.Errorf("cannot descend into absent field %v", path[0])
+		}
+		step.childType = nil
+	}
+
+	if step.childType == nil && len(path) > 1 {
+		return fmt.Errorf("cannot descend into basic element %v", path[0])
+	}
+
+	return nil
+}
+
+// getProofChildValue returns the value of the child selected by a path step.
+// For unions, the child type of the step is set to the type of the selected variant.
+func getProofChildValue(sourceType *TypeDescriptor, sourceValue reflect.Value, step *proofPathStep) (reflect.Value, error) {
+	switch sourceType.SszType {
+	case SszContainerType, SszProgressiveContainerType, SszStableContainerType:
+		return sourceValue.Field(step.fieldIndex), nil
+	case SszCompatibleUnionType:
+		selector := uint8(sourceValue.Field(0).Uint())
+		step.childType = sourceType.UnionVariants[selector]
+		if step.childType == nil {
+			return reflect.Value{}, sszutils.ErrInvalidUnionVariant
+		}
+		return getUnionVariantValue(step.childType, selector, sourceValue.Field(1))
+	case SszUnionType:
+		selector := uint8(sourceValue.Field(0).Uint())
+		step.childType = sourceType.UnionVariants[selector]
+		if step.childType == nil || sourceValue.Field(1).IsNil() {
+			return reflect.Value{}, fmt.Errorf("cannot descend into None union value")
+		}
+		return getUnionVariantValue(step.childType, selector, sourceValue.Field(1))
+	case SszOptionalType:
+		if sourceValue.IsNil() {
+			return reflect.Value{}, fmt.Errorf("cannot descend into absent optional value")
+		}
+		return sourceValue.Elem(), nil
+	default:
+		if step.elemIndex < sourceValue.Len() {
+			return sourceValue.Index(step.elemIndex), nil
+		}
+
+		// zero padded vector element
+		return reflect.New(step.childType.Type).Elem(), nil
+	}
+}
+
+// wrapProofStepError adds the path segment of a path step to an error from the selected child.
+func wrapProofStepError(err error, sourceType *TypeDescriptor, step *proofPathStep, pathElem string) error {
+	switch sourceType.SszType {
+	case SszContainerType, SszProgressiveContainerType, SszStableContainerType:
+		return wrapFieldError(err, pathElem)
+	case SszCompatibleUnionType, SszUnionType:
+		return wrapFieldError(err, "Data")
+	case SszOptionalType:
+		return err
+	default:
+		return wrapIndexError(err, step.elemIndex)
+	}
+}
+
+// unwrapProofValue resolves pointers, type wrappers and maps, which do not add a level to the hash tree.
+func (d *DynSsz) unwrapProofValue(sourceType *TypeDescriptor, sourceValue reflect.Value) (*TypeDescriptor, reflect.Value) {
+	for {
+		if sourceType.GoTypeFlags&GoTypeFlagIsPointer != 0 {
+			if sourceValue.IsNil() {
+				sourceValue = reflect.New(sourceType.Type.Elem()).Elem()
+			} else {
+				sourceValue = sourceValue.Elem()
+			}
+		}
+
+		switch sourceType.SszType {
+		case SszTypeWrapperType:
+			sourceType = sourceType.ElemDesc
+			sourceValue = sourceValue.Field(0)
+		case SszMapType:
+			// maps are hashed as their sorted entry list
+			sourceValue = getMapEntries(sourceType, sourceValue)
+			sourceType = sourceType.ElemDesc
+		default:
+			return sourceType, sourceValue
+		}
+	}
+}
+
+// resolveProofPathStep resolves a single path element against a composite type.
+func (d *DynSsz) resolveProofPathStep(sourceType *TypeDescriptor, pathElem string) (*proofPathStep, error) {
+	if sourceType.SszType == SszCustomType || sourceType.SszCompatFlags&SszCompatFlagDynamicHashRoot != 0 {
+		return nil, fmt.Errorf("cannot descend into type %v with custom hashing", sourceType.Type)
+	}
+
+	switch sourceType.SszType {
+	case SszContainerType, SszProgressiveContainerType, SszStableContainerType:
+		for i, field := range sourceType.ContainerDesc.Fields {
+			if field.Name != pathElem {
+				continue
+			}
+
+			chunkIndex := uint64(i)
+			if sourceType.SszType == SszProgressiveContainerType {
+				chunkIndex = uint64(field.SszIndex)
+			}
+
+			return &proofPathStep{
+				chunkIndex: chunkIndex,
+				fieldIndex: i,
+				childType:  field.Type,
+				consumed:   true,
+			}, nil
+		}
+
+		return nil, fmt.Errorf("unknown field %v in type %v", pathElem, sourceType.Type)
+
+	case SszCompatibleUnionType, SszUnionType:
+		// the union data is the first chunk, the selector the second one.
+		// the union does not consume the path element, it is resolved against the selected variant.
+		// the child type depends on the selected variant, so it's resolved from the value.
+		return &proofPathStep{
+			chunkIndex: 0,
+			childType:  sourceType,
+		}, nil
+
+	case SszOptionalType:
+		// the optional value is the first chunk, the selector the second one.
+		// like unions, optionals do not consume the path element.
+		return &proofPathStep{
+			chunkIndex: 0,
+			childType:  sourceType.ElemDesc,
+		}, nil
+
+	case SszVectorType, SszBitvectorType, SszListType, SszBitlistType, SszProgressiveListType, SszProgressiveBitlistType:
+		index, err := strconv.ParseUint(pathElem, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid element index %v for type %v", pathElem, sourceType.Type)
+		}
+
+		step := &proofPathStep{
+			elemIndex: int(index),
+			consumed:  true,
+		}
+
+		switch sourceType.SszType {
+		case SszVectorType:
+			if index >= uint64(sourceType.Len) {
+				return nil, fmt.Errorf("index %v out of range (length: %v)", index, sourceType.Len)
+			}
+		case SszBitvectorType:
+			if index >= uint64(sourceType.BitSize) {
+				return nil, fmt.Errorf("index %v out of range (length: %v)", index, sourceType.BitSize)
+			}
+		case SszListType, SszBitlistType:
+			if sourceType.SszTypeFlags&SszTypeFlagHasLimit != 0 && index >= sourceType.Limit {
+				return nil, fmt.Errorf("index %v out of range (limit: %v)", index, sourceType.Limit)
+			}
+		}
+
+		switch {
+		case sourceType.SszType == SszBitvectorType || sourceType.SszType == SszBitlistType || sourceType.SszType == SszProgressiveBitlistType:
+			step.chunkIndex = index / 256
+		case getPackedItemSize(sourceType.ElemDesc) > 0:
+			step.chunkIndex = index * getPackedItemSize(sourceType.ElemDesc) / 32
+		default:
+			step.chunkIndex = index
+			step.childType = sourceType.ElemDesc
+		}
+
+		return step, nil
+
+	default:
+		return nil, fmt.Errorf("cannot descend into basic type %v", sourceType.Type)
+	}
+}
+
+// getPackedItemSize returns the size of basic types that are packed into shared chunks when used
+// as list or vector elements, or 0 for types that contribute their own root.
+func getPackedItemSize(sourceType *TypeDescriptor) uint64 {
+	switch sourceType.SszType {
+	case SszBoolType, SszUint8Type:
+		return 1
+	case SszUint16Type:
+		return 2
+	case SszUint32Type:
+		return 4
+	case SszUint64Type:
+		return 8
+	case SszUint128Type:
+		return 16
+	case SszUint256Type:
+		return 32
+	default:
+		return 0
+	}
+}
+
+// getMerkleLayout returns the merkle tree layout of a composite type.
+//
+// The tree depth of lists and bitlists without a ssz-max limit depends on the actual value.
+// For these types the layout can only be determined if a valid sourceValue is passed.
+func (d *DynSsz) getMerkleLayout(sourceType *TypeDescriptor, sourceValue reflect.Value) (*merkleLayout, error) {
+	switch sourceType.SszType {
+	case SszContainerType:
+		return &merkleLayout{
+			limit: uint64(len(sourceType.ContainerDesc.Fields)),
+		}, nil
+	case SszProgressiveContainerType, SszProgressiveListType, SszProgressiveBitlistType:
+		return &merkleLayout{
+			progressive: true,
+			mixin:       true,
+		}, nil
+	case SszStableContainerType:
+		return &merkleLayout{
+			limit: uint64(sourceType.Len),
+			mixin: true,
+		}, nil
+	case SszCompatibleUnionType, SszUnionType, SszOptionalType:
+		return &merkleLayout{
+			limit: 2,
+		}, nil
+	case SszVectorType:
+		if itemSize := getPackedItemSize(sourceType.ElemDesc); itemSize > 0 {
+			return &merkleLayout{
+				limit: (uint64(sourceType.Len)*itemSize + 31) / 32,
+			}, nil
+		}
+		return &merkleLayout{
+			limit: uint64(sourceType.Len),
+		}, nil
+	case SszBitvectorType:
+		return &merkleLayout{
+			limit: (uint64(sourceType.Len) + 31) / 32,
+		}, nil
+	case SszBitlistType:
+		maxSize := sourceType.Limit
+		if sourceType.SszTypeFlags&SszTypeFlagHasLimit == 0 {
+			if !sourceValue.IsValid() {
+				return nil, fmt.Errorf("tree depth of bitlist type %v without ssz-max depends on the value", sourceType.Type)
+			}
+			maxSize = uint64(sourceValue.Len() * 8)
+		}
+		return &merkleLayout{
+			limit: (maxSize + 255) / 256,
+			mixin: true,
+		}, nil
+	case SszListType:
+		itemSize := getPackedItemSize(sourceType.ElemDesc)
+
+		if sourceType.SszTypeFlags&SszTypeFlagHasLimit == 0 {
+			// lists without limit are merkleized without length mixin
+			if !sourceValue.IsValid() {
+				return nil, fmt.Errorf("tree depth of list type %v without ssz-max depends on the value", sourceType.Type)
+			}
+
+			chunkCount := uint64(sourceValue.Len())
+			if itemSize > 0 {
+				chunkCount = (chunkCount*itemSize + 31) / 32
+			}
+			return &merkleLayout{
+				limit: chunkCount,
+			}, nil
+		}
+
+		if itemSize > 0 {
+			itemCount := uint64(0)
+			if sourceValue.IsValid() {
+				itemCount = uint64(sourceValue.Len())
+			}
+			return &merkleLayout{
+				limit: sszutils.CalculateLimit(sourceType.Limit, itemCount, itemSize),
+				mixin: true,
+			}, nil
+		}
+		return &merkleLayout{
+			limit: sourceType.Limit,
+			mixin: true,
+		}, nil
+	default:
+		return nil, fmt.Errorf("type %v is not a composite type", sourceType.Type)
+	}
+}
+
+// getChunkGeneralizedIndex returns the generalized index of a chunk relative to the root of the value.
+func (l *merkleLayout) getChunkGeneralizedIndex(chunkIndex uint64) (uint64, error) {
+	var gindex uint64
+	if l.progressive {
+		var err error
+		gindex, err = getProgressiveGeneralizedIndex(chunkIndex)
+		if err != nil {
+			return 0, err
+		}
+	} else {
+		depth := getTreeDepth(l.limit)
+		if depth >= 64 {
+			return 0, fmt.Errorf("%w (tree depth: %d)", sszutils.ErrGindexOverflow, depth)
+		}
+		gindex = uint64(1)<<depth | chunkIndex
+	}
+	if l.mixin {
+		return concatGeneralizedIndex(2, gindex)
+	}
+	return gindex, nil
+}
+
+// getRoot merkleizes the chunks of a composite value and mixes in the mixin chunk (if any).
+func (l *merkleLayout) getRoot(chunks [][32]byte, mixin [32]byte) [32]byte {
+	var root [32]byte
+	if l.progressive {
+		root = merkleizeProgressiveChunks(chunks, 0)
+	} else {
+		root = merkleizeChunks(chunks, getTreeDepth(l.limit))
+	}
+	if l.mixin {
+		root = hashPair(root, mixin)
+	}
+	return root
+}
+
+// getMerkleChunks returns the chunks of a composite value together with the chunk mixed into the content root (if any).
+func (d *DynSsz) getMerkleChunks(sourceType *TypeDescriptor, sourceValue reflect.Value) ([][32]byte, [32]byte, error) {
+	var mixin [32]byte
+
+	switch sourceType.SszType {
+	case SszContainerType, SszProgressiveContainerType:
+		chunkCount := len(sourceType.ContainerDesc.Fields)
+		if sourceType.SszType == SszProgressiveContainerType && chunkCount > 0 {
+			chunkCount = int(sourceType.ContainerDesc.Fields[chunkCount-1].SszIndex) + 1
+		}
+
+		chunks := make([][32]byte, chunkCount)
+		for i, field := range sourceType.ContainerDesc.Fields {
+			chunkIndex := i
+			if sourceType.SszType == SszProgressiveContainerType {
+				chunkIndex = int(field.SszIndex)
+			}
+
+			root, err := d.getValueRoot(field.Type, sourceValue.Field(i))
+			if err != nil {
+				return nil, mixin, err
+			}
+			chunks[chunkIndex] = root
+		}
+
+		if sourceType.SszType == SszProgressiveContainerType {
+			copy(mixin[:], d.getActiveFields(sourceType))
+		}
+
+		return chunks, mixin, nil
+
+	case SszStableContainerType:
+		// absent fields are zero chunks, the mixin is the root of the active fields bitvector
+		chunks := make([][32]byte, len(sourceType.ContainerDesc.Fields))
+		activeFields := make([]byte, (sourceType.Len+7)/8)
+		for i, field := range sourceType.ContainerDesc.Fields {
+			fieldValue := sourceValue.Field(i)
+			if fieldValue.IsNil() {
+				continue
+			}
+
+			root, err := d.getValueRoot(field.Type, fieldValue)
+			if err != nil {
+				return nil, mixin, err
+			}
+			chunks[i] = root
+			activeFields[i/8] |= 1 << (i % 8)
+		}
+
+		mixin = merkleizeChunks(packChunks(activeFields), getTreeDepth((uint64(sourceType.Len)+255)/256))
+
+		return chunks, mixin, nil
+
+	case SszCompatibleUnionType:
+		variant := uint8(sourceValue.Field(0).Uint())
+		variantDesc, ok := sourceType.UnionVariants[variant]
+		if !ok || sourceValue.Field(1).IsNil() {
+			return nil, mixin, sszutils.ErrInvalidUnionVariant
+		}
+
+		dataValue, err := getUnionVariantValue(variantDesc, variant, sourceValue.Field(1))
+		if err != nil {
+			return nil, mixin, err
+		}
+
+		root, err := d.getValueRoot(variantDesc, dataValue)
+		if err != nil {
+			return nil, mixin, err
+		}
+
+		selector := [32]byte{variant}
+		return [][32]byte{root, selector}, mixin, nil
+
+	case SszUnionType:
+		selector := uint8(sourceValue.Field(0).Uint())
+		variantDesc, ok := sourceType.UnionVariants[selector]
+		if !ok {
+			return nil, mixin, sszutils.ErrInvalidUnionVariant
+		}
+		if variantDesc == nil {
+			return [][32]byte{{}, {}}, mixin, nil
+		}
+		dataValue, err := getUnionVariantValue(variantDesc, selector, sourceValue.Field(1))
+		if err != nil {
+			return nil, mixin, err
+		}
+
+		root, err := d.getValueRoot(variantDesc, dataValue)
+		if err != nil {
+			return nil, mixin, err
+		}
+
+		return [][32]byte{root, {selector}}, mixin, nil
+
+	case SszOptionalType:
+		if sourceValue.IsNil() {
+			return [][32]byte{{}, {}}, mixin, nil
+		}
+
+		root, err := d.getValueRoot(sourceType.ElemDesc, sourceValue.Elem())
+		if err != nil {
+			return nil, mixin, err
+		}
+
+		return [][32]byte{root, {1}}, mixin, nil
+
+	case SszVectorType, SszBitvectorType, SszListType, SszBitlistType, SszProgressiveListType, SszProgressiveBitlistType:
+		itemCount := sourceValue.Len()
+		if sourceType.SszType != SszBitlistType && sourceType.SszType != SszProgressiveBitlistType {
+			putUint64Chunk(&mixin, uint64(itemCount))
+		}
+
+		switch {
+		case sourceType.SszType == SszBitlistType || sourceType.SszType == SszProgressiveBitlistType:
+			var bitlist []byte
+			var size uint64
+			if bytes := sourceValue.Bytes(); len(bytes) > 0 {
+				bitlist, size = hasher.ParseBitlist(nil, bytes)
+			}
+			putUint64Chunk(&mixin, size)
+			return packChunks(bitlist), mixin, nil
+
+		case sourceType.SszType == SszBitvectorType || getPackedItemSize(sourceType.ElemDesc) > 0:
+			// the chunks of packed basic types are their serialized representation
+			packed, err := d.marshalType(sourceType, sourceValue, nil, 0)
+			if err != nil {
+				return nil, mixin, err
+			}
+			return packChunks(packed), mixin, nil
+
+		default:
+			chunkCount := itemCount
+			if sourceType.SszType == SszVectorType {
+				chunkCount = int(sourceType.Len)
+			}
+			if itemCount > chunkCount {
+				return nil, mixin, sszutils.ErrListTooBig
+			}
+
+			chunks := make([][32]byte, chunkCount)
+			for i := 0; i < chunkCount; i++ {
+				var elemValue reflect.Value
+				if i < itemCount {
+					elemValue = sourceValue.Index(i)
+				} else if sourceType.ElemDesc.GoTypeFlags&GoTypeFlagIsPointer != 0 {
+					elemValue = reflect.New(sourceType.ElemDesc.Type.Elem())
+				} else {
+					elemValue = reflect.New(sourceType.ElemDesc.Type).Elem()
+				}
+
+				root, err := d.getValueRoot(sourceType.ElemDesc, elemValue)
+				if err != nil {
+					return nil, mixin, err
+				}
+				chunks[i] = root
+			}
+
+			return chunks, mixin, nil
+		}
+
+	default:
+		return nil, mixin, fmt.Errorf("type %v is not a composite type", sourceType.Type)
+	}
+}
+
+// getValueRoot computes the hash tree root of a single value using a pooled hasher.
+func (d *DynSsz) getValueRoot(sourceType *TypeDescriptor, sourceValue reflect.Value) ([32]byte, error) {
+	pool := d.getHasherPool()
+	hh := pool.Get()
+	defer pool.Put(hh)
+
+	err := d.buildRootFromType(sourceType, sourceValue, hh, false, 0)
+	if err != nil {
+		return [32]byte{}, err
+	}
+
+	return hh.HashRoot()
+}
+
+// packChunks splits the packed serialization of basic values into zero padded 32 byte chunks.
+func packChunks(data []byte) [][32]byte {
+	chunks := make([][32]byte, (len(data)+31)/32)
+	for i := range chunks {
+		copy(chunks[i][:], data[i*32:])
+	}
+	return chunks
+}
+
+// putUint64Chunk writes a little endian uint64 into the first 8 bytes of a chunk.
+func putUint64Chunk(chunk *[32]byte, value uint64) {
+	*chunk = [32]byte{}
+	for i := 0; i < 8; i++ {
+		chunk[i] = byte(value >> (8 * i))
+	}
+}
+
+var proofZeroHashes [65][32]byte
+var proofZeroHashesOnce sync.Once
+
+// getZeroHash returns the root of an empty binary tree of the given depth.
+func getZeroHash(depth int) [32]byte {
+	proofZeroHashesOnce.Do(func() {
+		for i := 0; i < 64; i++ {
+			proofZeroHashes[i+1] = hashPair(proofZeroHashes[i], proofZeroHashes[i])
+		}
+	})
+	return proofZeroHashes[depth]
+}
+
+// hashPair computes the sha256 hash of two concatenated nodes.
+func hashPair(left, right [32]byte) [32]byte {
+	var buf [64]byte
+	copy(buf[:32], left[:])
+	copy(buf[32:], right[:])
+	return sha256.Sum256(buf[:])
+}
+
+// getTreeDepth returns the depth of a binary merkle tree with the given number of leaves.
+func getTreeDepth(limit uint64) int {
+	if limit <= 1 {
+		return 0
+	}
+	return bits.Len64(limit - 1)
+}
+
+// concatGeneralizedIndex returns the generalized index of a node given by its index relative to a subtree root.
+// It fails with sszutils.ErrGindexOverflow if the resulting index does not fit into 64 bits.
+func concatGeneralizedIndex(parent, child uint64) (uint64, error) {
+	if bits.Len64(parent)+bits.Len64(child)-1 > 64 {
+		return 0, fmt.Errorf("%w (tree depth: %d)", sszutils.ErrGindexOverflow, bits.Len64(parent)+bits.Len64(child)-2)
+	}
+
+	depth := bits.Len64(child) - 1
+	return parent<<depth | (child ^ (1 << depth)), nil
+}
+
+// merkleizeChunks computes the root of a binary merkle tree of the given depth, padding missing chunks with zero hashes.
+func merkleizeChunks(chunks [][32]byte, depth int) [32]byte {
+	if len(chunks) == 0 {
+		return getZeroHash(depth)
+	}
+
+	layer := make([][32]byte, len(chunks))
+	copy(layer, chunks)
+
+	for level := 0; level < depth; level++ {
+		if len(layer)%2 == 1 {
+			layer = append(layer, getZeroHash(level))
+		}
+		for i := 0; i < len(layer)/2; i++ {
+			layer[i] = hashPair(layer[2*i], layer[2*i+1])
+		}
+		layer = layer[:len(layer)/2]
+	}
+
+	return layer[0]
+}
+
+// proveChunks returns the sibling hashes of a chunk in a binary merkle tree.
+func proveChunks(chunks [][32]byte, depth int, index uint64) [][32]byte {
+	hashes := make([][32]byte, 0, depth)
+
+	layer := make([][32]byte, len(chunks))
+	copy(layer, chunks)
+
+	position := index
+	for level := 0; level < depth; level++ {
+		if len(layer)%2 == 1 {
+			layer = append(layer, getZeroHash(level))
+		}
+
+		sibling := position ^ 1
+		if sibling < uint64(len(layer)) {
+			hashes = append(hashes, layer[sibling])
+		} else {
+			hashes = append(hashes, getZeroHash(level))
+		}
+
+		for i := 0; i < len(layer)/2; i++ {
+			layer[i] = hashPair(layer[2*i], layer[2*i+1])
+		}
+		layer = layer[:len(layer)/2]
+		position >>= 1
+	}
+
+	return hashes
+}
+
+// getProgressiveGeneralizedIndex returns the generalized index (relative to the tree root) of a chunk in a progressive merkle tree.
+func getProgressiveGeneralizedIndex(index uint64) (uint64, error) {
+	gindex := uint64(1)
+	depth := 0
+
+	for {
+		baseSize := uint64(1) << depth
+		if index < baseSize {
+			return concatGeneralizedIndex(gindex*2+1, baseSize|index)
+		}
+
+		gindex = gindex * 2
+		index -= baseSize
+		depth += 2
+	}
+}
+
+// merkleizeProgressiveChunks computes the root of a progressive merkle tree (EIP-7916).
+//
+// The first 1 << depth chunks form the binary right subtree, the remaining chunks are
+// merkleized progressively with depth + 2 in the left subtree.
+func merkleizeProgressiveChunks(chunks [][32]byte, depth int) [32]byte {
+	if len(chunks) == 0 {
+		return [32]byte{}
+	}
+
+	baseSize := 1 << depth
+	if baseSize > len(chunks) {
+		baseSize = len(chunks)
+	}
+
+	right := merkleizeChunks(chunks[:baseSize], depth)
+	left := merkleizeProgressiveChunks(chunks[baseSize:], depth+2)
+
+	return hashPair(left, right)
+}
+
+// proveProgressiveChunks returns the sibling hashes of a chunk in a progressive merkle tree.
+func proveProgressiveChunks(chunks [][32]byte, index uint64) [][32]byte {
+	levelHashes := [][32]byte{}
+	depth := 0
+
+	for {
+		baseSize := uint64(1) << depth
+		splitPoint := baseSize
+		if splitPoint > uint64(len(chunks)) {
+			splitPoint = uint64(len(chunks))
+		}
+
+		if index < baseSize {
+			// chunk is in the binary right subtree, sibling is the progressive left subtree
+			levelHashes = append(levelHashes, merkleizeProgressiveChunks(chunks[splitPoint:], depth+2))
+
+			subHashes := proveChunks(chunks[:splitPoint], depth, index)
+
+			// reverse order of the progressive levels (leaf first)
+			for i := len(levelHashes) - 1; i >= 0; i-- {
+				subHashes = append(subHashes, levelHashes[i])
+			}
+
+			return subHashes
+		}
+
+		// chunk is in the progressive left subtree, sibling is the binary right subtree
+		levelHashes = append(levelHashes, merkleizeChunks(chunks[:splitPoint], depth))
+		index -= baseSize
+		chunks = chunks[splitPoint:]
+		depth += 2
+	}
+}
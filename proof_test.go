@@ -0,0 +1,423 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+	"github.com/pk910/dynamic-ssz/sszutils"
+)
+
+type proofTestElement struct {
+	Index uint64
+	Data  []byte `ssz-max:"32"`
+}
+
+type proofTestProgressive struct {
+	Field0 uint64   `ssz-index:"0"`
+	Field1 []uint16 `ssz-index:"2" ssz-max:"16"`
+	Field2 bool     `ssz-index:"5"`
+}
+
+type proofTestStable struct {
+	A *uint64
+	B *proofTestElement
+	C *uint16
+}
+
+type proofTestContainer struct {
+	Slot        uint64
+	Root        [32]byte
+	Balances    []uint64            `ssz-max:"1024"`
+	Elements    []*proofTestElement `ssz-max:"16"`
+	Fixed       [5]proofTestElement
+	Bits        []byte   `ssz-type:"bitlist" ssz-max:"512"`
+	Progressive []uint32 `ssz-type:"progressive-list"`
+	Container   proofTestProgressive
+	Unbounded   []uint16
+	Union       CompatibleUnion[struct {
+		Element proofTestElement
+		Value   uint64
+	}]
+	Optional *proofTestElement            `ssz-type:"optional"`
+	Stable   proofTestStable              `ssz-type:"stable-container" ssz-size:"4"`
+	Map      map[uint64]*proofTestElement `ssz-max:"8"`
+	Maybe    Union[struct {
+		None    UnionNone
+		Element proofTestElement
+	}]
+}
+
+func TestGetProof(t *testing.T) {
+	payload := &proofTestContainer{
+		Slot:     1337,
+		Root:     [32]byte{1, 2, 3},
+		Balances: []uint64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+		Elements: []*proofTestElement{
+			{Index: 1, Data: []byte{1, 2, 3}},
+			{Index: 2},
+			nil,
+			{Index: 4, Data: []byte{4}},
+		},
+		Fixed: [5]proofTestElement{
+			{Index: 5, Data: []byte{5, 5}},
+		},
+		Bits:        []byte{0xaa, 0x55, 0x03},
+		Progressive: []uint32{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22},
+		Container: proofTestProgressive{
+			Field0: 42,
+			Field1: []uint16{1, 2, 3},
+			Field2: true,
+		},
+		Unbounded: []uint16{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17},
+		Optional:  &proofTestElement{Index: 3},
+		Stable: proofTestStable{
+			A: func() *uint64 { v := uint64(11); return &v }(),
+			B: &proofTestElement{Index: 12, Data: []byte{12}},
+		},
+		Map: map[uint64]*proofTestElement{
+			7: {Index: 7},
+			3: {Index: 3, Data: []byte{3}},
+		},
+	}
+	payload.Union.Variant = 0
+	payload.Union.Data = proofTestElement{Index: 9, Data: []byte{9}}
+	payload.Maybe.Selector = 1
+	payload.Maybe.Data = proofTestElement{Index: 10, Data: []byte{10}}
+
+	testCases := []struct {
+		path   []string
+		gindex uint64
+	}{
+		{[]string{}, 1},
+		{[]string{"Slot"}, 16},
+		{[]string{"Root"}, 17},
+		{[]string{"Balances"}, 18},
+		{[]string{"Balances", "0"}, 18<<9 | 0},
+		{[]string{"Balances", "9"}, 18<<9 | 2},
+		{[]string{"Elements", "0"}, 19<<5 | 0},
+		{[]string{"Elements", "2"}, 19<<5 | 2},
+		{[]string{"Elements", "3", "Index"}, (19<<5|3)<<1 | 0},
+		{[]string{"Elements", "3", "Data"}, (19<<5|3)<<1 | 1},
+		{[]string{"Fixed", "0", "Data"}, (20<<3|0)<<1 | 1},
+		{[]string{"Fixed", "4", "Index"}, (20<<3|4)<<1 | 0},
+		{[]string{"Bits", "3"}, 21<<2 | 0},
+		{[]string{"Bits", "16"}, 21<<2 | 0},
+		{[]string{"Progressive", "0"}, 22<<2 | 1},
+		{[]string{"Progressive", "21"}, 0},
+		{[]string{"Container", "Field0"}, 23<<2 | 1},
+		{[]string{"Container", "Field1", "2"}, 0},
+		{[]string{"Container", "Field2"}, 0},
+		{[]string{"Unbounded", "16"}, 0},
+		{[]string{"Union"}, 25},
+		{[]string{"Union", "Data"}, (25<<1)<<1 | 1},
+		{[]string{"Optional"}, 26},
+		{[]string{"Optional", "Index"}, (26<<1)<<1 | 0},
+		{[]string{"Stable", "A"}, (27<<1)<<2 | 0},
+		{[]string{"Stable", "B", "Data"}, ((27<<1)<<2|1)<<1 | 1},
+		{[]string{"Stable", "C"}, (27<<1)<<2 | 2},
+		{[]string{"Map", "0", "Key"}, ((28<<1)<<3|0)<<1 | 0},
+		{[]string{"Map", "1", "Value", "Data"}, (((28<<1)<<3|1)<<1|1)<<1 | 1},
+		{[]string{"Maybe"}, 29},
+		{[]string{"Maybe", "Data"}, (29<<1)<<1 | 1},
+	}
+
+	ds := NewDynSsz(nil)
+
+	root, err := ds.HashTreeRoot(payload)
+	if err != nil {
+		t.Fatalf("failed to hash payload: %v", err)
+	}
+
+	for _, tc := range testCases {
+		proof, err := ds.GetProof(payload, tc.path)
+		if err != nil {
+			t.Errorf("path %v: unexpected error: %v", tc.path, err)
+			continue
+		}
+
+		if tc.gindex != 0 && proof.GeneralizedIndex != tc.gindex {
+			t.Errorf("path %v: generalized index mismatch, expected %v, got %v", tc.path, tc.gindex, proof.GeneralizedIndex)
+		}
+
+		if !proof.Verify(root) {
+			t.Errorf("path %v: proof does not verify against the hash tree root", tc.path)
+		}
+	}
+}
+
+func TestGetProofLeaf(t *testing.T) {
+	payload := struct {
+		A uint64
+		B []uint64 `ssz-max:"8"`
+		C uint16
+	}{7, []uint64{1, 2, 3, 4, 5}, 3}
+
+	ds := NewDynSsz(nil)
+
+	proof, err := ds.GetProof(payload, []string{"A"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedLeaf := [32]byte{7}
+	if proof.Leaf != expectedLeaf {
+		t.Errorf("leaf mismatch, expected %x, got %x", expectedLeaf, proof.Leaf)
+	}
+
+	// packed basic elements are proven by the chunk that contains them
+	proof, err = ds.GetProof(payload, []string{"B", "4"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedLeaf = [32]byte{5}
+	if proof.Leaf != expectedLeaf {
+		t.Errorf("leaf mismatch, expected %x, got %x", expectedLeaf, proof.Leaf)
+	}
+
+	// the length mixin adds one level: field 1 of 3 at depth 2, content root, chunk 1 of 2
+	if proof.GeneralizedIndex != (5<<1)<<1|1 {
+		t.Errorf("generalized index mismatch, expected %v, got %v", (5<<1)<<1|1, proof.GeneralizedIndex)
+	}
+	if len(proof.Hashes) != 4 {
+		t.Errorf("branch length mismatch, expected 4, got %v", len(proof.Hashes))
+	}
+
+	root, err := ds.HashTreeRoot(payload)
+	if err != nil {
+		t.Fatalf("failed to hash payload: %v", err)
+	}
+	if !proof.Verify(root) {
+		t.Errorf("proof does not verify against the hash tree root")
+	}
+
+	proof.Leaf[0]++
+	if proof.Verify(root) {
+		t.Errorf("modified proof verifies against the hash tree root")
+	}
+}
+
+func TestGetProofErrors(t *testing.T) {
+	payload := struct {
+		A uint64
+		B []uint64 `ssz-max:"8"`
+		C [2]uint16
+		S struct {
+			A *uint64
+		} `ssz-type:"stable-container" ssz-size:"2"`
+		U Union[struct {
+			None  UnionNone
+			Value proofTestElement
+		}]
+	}{A: 7, B: []uint64{1, 2, 3}, C: [2]uint16{1, 2}}
+
+	testCases := []struct {
+		path        []string
+		expectedErr string
+	}{
+		{[]string{"D"}, "unknown field D"},
+		{[]string{"A", "0"}, "cannot descend into basic type"},
+		{[]string{"B", "x"}, "invalid element index x"},
+		{[]string{"B", "3"}, "index 3 out of range (length: 3)"},
+		{[]string{"B", "8"}, "index 8 out of range (limit: 8)"},
+		{[]string{"B", "1", "0"}, "cannot descend into basic element 1"},
+		{[]string{"C", "2"}, "index 2 out of range (length: 2)"},
+		{[]string{"S", "A", "0"}, "cannot descend into absent field A"},
+		{[]string{"U", "Index"}, "cannot descend into None union value"},
+	}
+
+	ds := NewDynSsz(nil)
+
+	for _, tc := range testCases {
+		_, err := ds.GetProof(payload, tc.path)
+		if err == nil {
+			t.Errorf("path %v: expected error, got nil", tc.path)
+		} else if !contains(err.Error(), tc.expectedErr) {
+			t.Errorf("path %v: expected error containing '%s', got '%s'", tc.path, tc.expectedErr, err.Error())
+		}
+	}
+}
+
+func TestGetGeneralizedIndex(t *testing.T) {
+	payload := &proofTestContainer{
+		Balances:    make([]uint64, 10),
+		Elements:    []*proofTestElement{{Index: 1}, {Index: 2}, {Index: 3}, {Index: 4}},
+		Bits:        []byte{0xaa, 0x55, 0x03},
+		Progressive: make([]uint32, 22),
+		Container: proofTestProgressive{
+			Field1: []uint16{1, 2, 3},
+		},
+		Optional: &proofTestElement{},
+		Stable: proofTestStable{
+			B: &proofTestElement{},
+		},
+		Map: map[uint64]*proofTestElement{1: {}, 2: {}},
+	}
+	payload.Union.Data = proofTestElement{}
+
+	paths := [][]string{
+		{},
+		{"Slot"},
+		{"Balances"},
+		{"Balances", "9"},
+		{"Elements", "3", "Data"},
+		{"Fixed", "4", "Index"},
+		{"Bits", "16"},
+		{"Progressive", "0"},
+		{"Progressive", "21"},
+		{"Container", "Field1", "2"},
+		{"Container", "Field2"},
+		{"Optional", "Index"},
+		{"Stable", "A"},
+		{"Stable", "B", "Data"},
+		{"Map", "1", "Value", "Data"},
+	}
+
+	ds := NewDynSsz(nil)
+
+	for _, path := range paths {
+		gindex, err := ds.GetGeneralizedIndex(reflect.TypeOf(payload), path)
+		if err != nil {
+			t.Errorf("path %v: unexpected error: %v", path, err)
+			continue
+		}
+
+		proof, err := ds.GetProof(payload, path)
+		if err != nil {
+			t.Fatalf("path %v: failed to generate proof: %v", path, err)
+		}
+
+		if gindex != proof.GeneralizedIndex {
+			t.Errorf("path %v: generalized index mismatch, expected %v, got %v", path, proof.GeneralizedIndex, gindex)
+		}
+	}
+}
+
+func TestGetGeneralizedIndexErrors(t *testing.T) {
+	testCases := []struct {
+		path        []string
+		expectedErr string
+	}{
+		{[]string{"Unknown"}, "unknown field Unknown"},
+		{[]string{"Slot", "0"}, "cannot descend into basic type"},
+		{[]string{"Balances", "x"}, "invalid element index x"},
+		{[]string{"Balances", "1024"}, "index 1024 out of range (limit: 1024)"},
+		{[]string{"Balances", "1", "0"}, "cannot descend into basic element 1"},
+		{[]string{"Fixed", "5"}, "index 5 out of range (length: 5)"},
+		{[]string{"Unbounded", "0"}, "without ssz-max depends on the value"},
+		{[]string{"Union", "Data"}, "without a value"},
+		{[]string{"Maybe", "Data"}, "without a value"},
+	}
+
+	ds := NewDynSsz(nil)
+
+	for _, tc := range testCases {
+		_, err := ds.GetGeneralizedIndex(reflect.TypeOf(proofTestContainer{}), tc.path)
+		if err == nil {
+			t.Errorf("path %v: expected error, got nil", tc.path)
+		} else if !contains(err.Error(), tc.expectedErr) {
+			t.Errorf("path %v: expected error containing '%s', got '%s'", tc.path, tc.expectedErr, err.Error())
+		}
+	}
+}
+
+func TestGetProofPathErrors(t *testing.T) {
+	payload := &proofTestContainer{
+		Bits: []byte{0x03},
+		Elements: []*proofTestElement{
+			{Index: 1},
+		},
+	}
+	payload.Union.Data = proofTestElement{Data: []byte{1}}
+
+	testCases := []struct {
+		path         []string
+		expectedPath string
+		expectedErr  string
+	}{
+		{[]string{"Balances", "0"}, "proofTestContainer.Balances", "index 0 out of range (length: 0)"},
+		{[]string{"Elements", "0", "Missing"}, "proofTestContainer.Elements[0]", "unknown field Missing"},
+		{[]string{"Union", "Data", "0", "1"}, "proofTestContainer.Union.Data.Data", "cannot descend into basic element 0"},
+	}
+
+	ds := NewDynSsz(nil)
+
+	for _, tc := range testCases {
+		_, err := ds.GetProof(payload, tc.path)
+
+		var pathErr *PathError
+		if !errors.As(err, &pathErr) {
+			t.Errorf("path %v: expected path error, got %v", tc.path, err)
+			continue
+		}
+		if pathErr.Path != tc.expectedPath {
+			t.Errorf("path %v: expected error path %v, got %v", tc.path, tc.expectedPath, pathErr.Path)
+		}
+		if !contains(pathErr.Err.Error(), tc.expectedErr) {
+			t.Errorf("path %v: expected error containing '%s', got '%s'", tc.path, tc.expectedErr, pathErr.Err.Error())
+		}
+	}
+}
+
+type proofTestDeepItem struct {
+	Values []*proofTestElement `ssz-max:"1099511627776"`
+}
+
+type proofTestDeepList struct {
+	Items []*proofTestDeepItem `ssz-max:"1099511627776"`
+}
+
+func TestGeneralizedIndexOverflow(t *testing.T) {
+	payload := &proofTestDeepList{
+		Items: []*proofTestDeepItem{
+			{Values: []*proofTestElement{{Index: 1}}},
+		},
+	}
+
+	ds := NewDynSsz(nil)
+
+	// a single level still fits into 64 bits
+	proof, err := ds.GetProof(payload, []string{"Items", "0"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	root, err := ds.HashTreeRoot(payload)
+	if err != nil {
+		t.Fatalf("failed to hash payload: %v", err)
+	}
+	if !proof.Verify(root) {
+		t.Errorf("proof does not verify against the hash tree root")
+	}
+
+	// two nested lists with a 2^40 limit need more than 64 bits
+	path := []string{"Items", "0", "Values", "0"}
+	checkOverflow := func(op string, err error) {
+		t.Helper()
+		if !errors.Is(err, sszutils.ErrGindexOverflow) {
+			t.Errorf("%v: expected generalized index overflow, got %v", op, err)
+		}
+	}
+
+	_, err = ds.GetProof(payload, path)
+	checkOverflow("proof", err)
+
+	_, err = ds.GetGeneralizedIndex(reflect.TypeOf(payload), path)
+	checkOverflow("generalized index", err)
+
+	_, err = ds.GetMultiProof(payload, [][]string{{"Items", "0"}, path})
+	checkOverflow("multiproof", err)
+
+	_, err = ds.DumpTree(payload)
+	checkOverflow("dump tree", err)
+
+	// trees with 2^64 leaves have no room for the root bit
+	_, err = ds.GetGeneralizedIndex(reflect.TypeOf(struct {
+		Items []*proofTestDeepItem `ssz-max:"18446744073709551615"`
+	}{}), []string{"Items", "0"})
+	checkOverflow("full depth", err)
+}
@@ -0,0 +1,10 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+
+//go:build race
+
+package dynssz_test
+
+// raceEnabled is set when the tests run with the race detector, which randomly drops pooled objects.
+const raceEnabled = true
@@ -0,0 +1,110 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"unsafe"
+)
+
+// RootCache memoizes the hash tree roots of pointer values across HashTreeRoot calls.
+//
+// When a RootCache is assigned to DynSsz.RootCache, the root of every non-nil pointer value that is
+// hashed (e.g. a *BeaconState or the elements of a []*Validator list) is stored, keyed by the pointer
+// identity. Subsequent hashing operations reuse the cached root instead of descending into the value.
+//
+// The cache does not detect modifications. After changing a value, the caller must call MarkDirty for
+// the modified pointer and for every pointer that (directly or indirectly) contains it, including the
+// root object. Values that are not referenced by a pointer are always hashed, so a modified value field
+// only requires marking the pointers above it.
+//
+// The cache keeps the hashed objects alive until their entries are marked dirty or the cache is reset.
+//
+// Example:
+//
+//	cache := dynssz.NewRootCache()
+//	ds.RootCache = cache
+//
+//	root, _ := ds.HashTreeRoot(state)
+//	state.Validators[5].EffectiveBalance = 32000000000
+//	cache.MarkDirty(state.Validators[5], state)
+//	root, _ = ds.HashTreeRoot(state) // only re-hashes the changed validator and the state itself
+type RootCache struct {
+	mutex sync.RWMutex
+	roots map[unsafe.Pointer]rootCacheEntry
+}
+
+// rootCacheEntry is a cached root along with the type descriptor it was computed for.
+type rootCacheEntry struct {
+	desc *TypeDescriptor
+	root [32]byte
+}
+
+// NewRootCache creates a new, empty root cache.
+func NewRootCache() *RootCache {
+	return &RootCache{
+		roots: make(map[unsafe.Pointer]rootCacheEntry),
+	}
+}
+
+// MarkDirty removes the cached roots of the given pointers, so they are hashed again on the next call.
+// Passing nil pointers or non-pointer values returns an error.
+func (c *RootCache) MarkDirty(ptrs ...any) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, ptr := range ptrs {
+		ptrValue := reflect.ValueOf(ptr)
+		if ptrValue.Kind() != reflect.Ptr || ptrValue.IsNil() {
+			return fmt.Errorf("cannot mark non-pointer value of type %T as dirty", ptr)
+		}
+
+		delete(c.roots, ptrValue.UnsafePointer())
+	}
+
+	return nil
+}
+
+// Reset removes all cached roots.
+func (c *RootCache) Reset() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.roots = make(map[unsafe.Pointer]rootCacheEntry)
+}
+
+// Len returns the number of cached roots.
+func (c *RootCache) Len() int {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return len(c.roots)
+}
+
+// getRoot returns the cached root of the pointer, if it was cached for the same type descriptor.
+func (c *RootCache) getRoot(ptr unsafe.Pointer, desc *TypeDescriptor) ([32]byte, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	entry, ok := c.roots[ptr]
+	if !ok || entry.desc != desc {
+		return [32]byte{}, false
+	}
+
+	return entry.root, true
+}
+
+// setRoot stores the root of the pointer.
+func (c *RootCache) setRoot(ptr unsafe.Pointer, desc *TypeDescriptor, root []byte) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry := rootCacheEntry{
+		desc: desc,
+	}
+	copy(entry.root[:], root)
+	c.roots[ptr] = entry
+}
@@ -0,0 +1,103 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+type rootCacheTestValidator struct {
+	Pubkey  [48]byte
+	Balance uint64
+}
+
+type rootCacheTestState struct {
+	Slot       uint64
+	Validators []*rootCacheTestValidator `ssz-max:"1024"`
+	Latest     *rootCacheTestValidator
+	Balances   []uint64 `ssz-max:"1024"`
+}
+
+func TestRootCache(t *testing.T) {
+	state := &rootCacheTestState{
+		Slot:   1,
+		Latest: &rootCacheTestValidator{Balance: 1},
+	}
+	for i := 0; i < 300; i++ {
+		state.Validators = append(state.Validators, &rootCacheTestValidator{Pubkey: [48]byte{byte(i)}, Balance: uint64(i)})
+		state.Balances = append(state.Balances, uint64(i))
+	}
+
+	ds := NewDynSsz(nil)
+	cache := NewRootCache()
+	cachedDs := NewDynSsz(nil)
+	cachedDs.RootCache = cache
+	cachedDs.MaxConcurrency = 4
+
+	checkRoot := func(name string) {
+		t.Helper()
+
+		expected, err := ds.HashTreeRoot(state)
+		if err != nil {
+			t.Fatalf("%v: hashing failed: %v", name, err)
+		}
+
+		root, err := cachedDs.HashTreeRoot(state)
+		if err != nil {
+			t.Fatalf("%v: cached hashing failed: %v", name, err)
+		}
+		if root != expected {
+			t.Errorf("%v: root mismatch: got 0x%x, wanted 0x%x", name, root, expected)
+		}
+	}
+
+	checkRoot("initial")
+
+	// the state, all validators and the latest validator are cached
+	if cache.Len() != 302 {
+		t.Errorf("expected 302 cached roots, got %v", cache.Len())
+	}
+
+	checkRoot("unchanged")
+
+	// modifications are not visible until the pointers are marked dirty
+	staleRoot, _ := cachedDs.HashTreeRoot(state)
+	state.Validators[5].Balance = 1337
+	if root, _ := cachedDs.HashTreeRoot(state); root != staleRoot {
+		t.Errorf("expected the cached root before marking the state dirty")
+	}
+
+	if err := cache.MarkDirty(state.Validators[5], state); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cache.Len() != 300 {
+		t.Errorf("expected 300 cached roots, got %v", cache.Len())
+	}
+	checkRoot("modified validator")
+
+	// value fields are always hashed, only the containing pointers need to be marked
+	state.Slot = 2
+	state.Balances[7] = 42
+	cache.MarkDirty(state)
+	checkRoot("modified value fields")
+
+	// replaced pointers are not cached yet
+	state.Latest = &rootCacheTestValidator{Balance: 2}
+	state.Validators = append(state.Validators, &rootCacheTestValidator{Balance: 3})
+	cache.MarkDirty(state)
+	checkRoot("replaced pointers")
+
+	// a fully dirty cache produces the same root as the non-cached path
+	cache.Reset()
+	if cache.Len() != 0 {
+		t.Errorf("expected empty cache after reset, got %v", cache.Len())
+	}
+	checkRoot("reset")
+
+	if err := cache.MarkDirty(*state); err == nil || !contains(err.Error(), "cannot mark non-pointer value") {
+		t.Errorf("expected non-pointer error, got %v", err)
+	}
+}
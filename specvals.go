@@ -5,6 +5,7 @@ package dynssz
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/casbin/govaluate"
 )
@@ -15,11 +16,14 @@ type cachedSpecValue struct {
 }
 
 func (d *DynSsz) ResolveSpecValue(name string) (bool, uint64, error) {
-	if cachedValue := d.specValueCache[name]; cachedValue != nil {
+	d.specValueMutex.RLock()
+	cachedValue := d.specValueCache[name]
+	d.specValueMutex.RUnlock()
+	if cachedValue != nil {
 		return cachedValue.resolved, cachedValue.value, nil
 	}
 
-	cachedValue := &cachedSpecValue{}
+	cachedValue = &cachedSpecValue{}
 	expression, err := govaluate.NewEvaluableExpression(name)
 	if err != nil {
 		return false, 0, fmt.Errorf("error parsing dynamic spec expression: %v", err)
@@ -40,6 +44,30 @@ func (d *DynSsz) ResolveSpecValue(name string) (bool, uint64, error) {
 
 	// fmt.Printf("spec lookup %v,  ok: %v, value: %v\n", name, cachedValue.resolved, cachedValue.value)
 
+	d.specValueMutex.Lock()
 	d.specValueCache[name] = cachedValue
+	d.specValueMutex.Unlock()
+
 	return cachedValue.resolved, cachedValue.value, nil
 }
+
+// getUnresolvedSpecValueError returns an error describing why a spec expression could not be resolved.
+func (d *DynSsz) getUnresolvedSpecValueError(name string) error {
+	expression, err := govaluate.NewEvaluableExpression(name)
+	if err != nil {
+		return fmt.Errorf("error parsing dynamic spec expression: %v", err)
+	}
+
+	unknownValues := []string{}
+	for _, variable := range expression.Vars() {
+		if _, ok := d.specValues[variable]; !ok {
+			unknownValues = append(unknownValues, variable)
+		}
+	}
+
+	if len(unknownValues) > 0 {
+		return fmt.Errorf("unknown spec value %v in expression '%v'", strings.Join(unknownValues, ", "), name)
+	}
+
+	return fmt.Errorf("spec expression '%v' does not evaluate to a number", name)
+}
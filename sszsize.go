@@ -29,7 +29,7 @@ import (
 //
 // Returns:
 //   - uint32: The exact number of bytes needed to encode this value
-//   - error: An error if sizing fails (e.g., slice exceeds maximum size)
+//   - error: An error if sizing fails (e.g., vector exceeds its fixed size)
 //
 // Special handling:
 //   - Nil pointers are sized as zero-valued instances
@@ -101,7 +101,7 @@ func (d *DynSsz) getSszValueSize(targetType *TypeDescriptor, targetValue reflect
 				if fieldType.Type.SszTypeFlags&SszTypeFlagIsDynamic != 0 {
 					size, err := d.getSszValueSize(fieldType.Type, fieldValue)
 					if err != nil {
-						return 0, err
+						return 0, wrapFieldError(err, fieldType.Name)
 					}
 
 					// dynamic field, add 4 bytes for offset
@@ -112,6 +112,10 @@ func (d *DynSsz) getSszValueSize(targetType *TypeDescriptor, targetValue reflect
 				}
 			}
 		case SszVectorType, SszBitvectorType:
+			if err := validateVectorLength(targetType, targetValue.Len()); err != nil {
+				return 0, err
+			}
+
 			fieldType := targetType.ElemDesc
 			if fieldType.Kind == reflect.Uint8 {
 				staticSize = targetType.Len
@@ -122,7 +126,7 @@ func (d *DynSsz) getSszValueSize(targetType *TypeDescriptor, targetValue reflect
 				for i := 0; i < dataLen; i++ {
 					size, err := d.getSszValueSize(fieldType, targetValue.Index(i))
 					if err != nil {
-						return 0, err
+						return 0, wrapIndexError(err, i)
 					}
 					// add 4 bytes for offset in dynamic array
 					staticSize += size + 4
@@ -162,6 +166,10 @@ func (d *DynSsz) getSszValueSize(targetType *TypeDescriptor, targetValue reflect
 			fieldType := targetType.ElemDesc
 			sliceLen := uint32(targetValue.Len())
 
+			if err := d.validateListLimit(targetType, int(sliceLen)); err != nil {
+				return 0, err
+			}
+
 			if sliceLen > 0 {
 				if fieldType.Kind == reflect.Uint8 {
 					staticSize = uint32(sliceLen)
@@ -170,7 +178,7 @@ func (d *DynSsz) getSszValueSize(targetType *TypeDescriptor, targetValue reflect
 					for i := 0; i < int(sliceLen); i++ {
 						size, err := d.getSszValueSize(fieldType, targetValue.Index(i))
 						if err != nil {
-							return 0, err
+							return 0, wrapIndexError(err, i)
 						}
 						// add 4 bytes for offset in dynamic slice
 						staticSize += size + 4
@@ -179,6 +187,66 @@ func (d *DynSsz) getSszValueSize(targetType *TypeDescriptor, targetValue reflect
 					staticSize = uint32(fieldType.Size) * sliceLen
 				}
 			}
+		case SszStableContainerType:
+			// StableContainer: active fields bitvector + size of the active fields
+			staticSize = (targetType.Len + 7) / 8
+			for i, field := range targetType.ContainerDesc.Fields {
+				fieldValue := targetValue.Field(i)
+				if fieldValue.IsNil() {
+					continue
+				}
+
+				size, err := d.getSszValueSize(field.Type, fieldValue)
+				if err != nil {
+					return 0, wrapFieldError(err, field.Name)
+				}
+
+				if field.Type.SszTypeFlags&SszTypeFlagIsDynamic != 0 {
+					// add 4 bytes for offset
+					size += 4
+				}
+				staticSize += size
+			}
+		case SszMapType:
+			// Map: size of the sorted entry list
+			size, err := d.getSszValueSize(targetType.ElemDesc, getMapEntries(targetType, targetValue))
+			if err != nil {
+				return 0, err
+			}
+			staticSize = size
+		case SszOptionalType:
+			// Optional: empty if absent, 1 byte for selector + size of the value if present
+			if !targetValue.IsNil() {
+				size, err := d.getSszValueSize(targetType.ElemDesc, targetValue.Elem())
+				if err != nil {
+					return 0, err
+				}
+				staticSize = 1 + size
+			}
+		case SszUnionType:
+			// Union: 1 byte for selector + size of the data (none for the None option)
+			selector := uint8(targetValue.Field(0).Uint())
+			dataField := targetValue.Field(1)
+
+			variantDesc, ok := targetType.UnionVariants[selector]
+			if !ok {
+				return 0, fmt.Errorf("unknown union variant index: %d", selector)
+			}
+
+			staticSize = 1
+			if variantDesc != nil {
+				dataValue, err := getUnionVariantValue(variantDesc, selector, dataField)
+				if err != nil {
+					return 0, err
+				}
+
+				dataSize, err := d.getSszValueSize(variantDesc, dataValue)
+				if err != nil {
+					return 0, wrapFieldError(err, "Data")
+				}
+
+				staticSize += dataSize
+			}
 		case SszCompatibleUnionType:
 			// CompatibleUnion: 1 byte for selector + size of the data
 			variant := uint8(targetValue.Field(0).Uint())
@@ -190,10 +258,15 @@ func (d *DynSsz) getSszValueSize(targetType *TypeDescriptor, targetValue reflect
 				return 0, fmt.Errorf("unknown union variant index: %d", variant)
 			}
 
+			dataValue, err := getUnionVariantValue(variantDesc, variant, dataField)
+			if err != nil {
+				return 0, err
+			}
+
 			// Calculate size of the data
-			dataSize, err := d.getSszValueSize(variantDesc, dataField.Elem())
+			dataSize, err := d.getSszValueSize(variantDesc, dataValue)
 			if err != nil {
-				return 0, fmt.Errorf("failed to get size of union variant %d: %w", variant, err)
+				return 0, wrapFieldError(err, "Data")
 			}
 
 			staticSize = 1 + dataSize // 1 byte selector + data size
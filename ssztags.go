@@ -36,8 +36,50 @@ const (
 	SszProgressiveBitlistType
 	SszProgressiveContainerType
 	SszCompatibleUnionType
+	SszOptionalType
+	SszStableContainerType
+	SszMapType
+	SszUnionType
 )
 
+// sszTypeNames contains the names of the ssz types, as used in ssz-type tags.
+// SszUnionType cannot be selected by tag (the "union" tag maps to SszCompatibleUnionType), so it is
+// named "ssz-union" to keep the names unambiguous.
+var sszTypeNames = map[SszType]string{
+	SszUnspecifiedType:          "unspecified",
+	SszCustomType:               "custom",
+	SszTypeWrapperType:          "wrapper",
+	SszBoolType:                 "bool",
+	SszUint8Type:                "uint8",
+	SszUint16Type:               "uint16",
+	SszUint32Type:               "uint32",
+	SszUint64Type:               "uint64",
+	SszUint128Type:              "uint128",
+	SszUint256Type:              "uint256",
+	SszContainerType:            "container",
+	SszListType:                 "list",
+	SszVectorType:               "vector",
+	SszBitlistType:              "bitlist",
+	SszBitvectorType:            "bitvector",
+	SszProgressiveListType:      "progressive-list",
+	SszProgressiveBitlistType:   "progressive-bitlist",
+	SszProgressiveContainerType: "progressive-container",
+	SszCompatibleUnionType:      "compatible-union",
+	SszOptionalType:             "optional",
+	SszStableContainerType:      "stable-container",
+	SszMapType:                  "map",
+	SszUnionType:                "ssz-union",
+}
+
+// String returns the name of the ssz type.
+func (t SszType) String() string {
+	if name, ok := sszTypeNames[t]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("SszType(%d)", uint8(t))
+}
+
 type SszTypeHint struct {
 	Type SszType
 }
@@ -93,6 +135,12 @@ func (d *DynSsz) getSszTypeTag(field *reflect.StructField) ([]SszTypeHint, error
 				sszType.Type = SszProgressiveContainerType
 			case "compatible-union", "union":
 				sszType.Type = SszCompatibleUnionType
+			case "optional":
+				sszType.Type = SszOptionalType
+			case "stable-container", "stablecontainer":
+				sszType.Type = SszStableContainerType
+			case "map":
+				sszType.Type = SszMapType
 
 			default:
 				return nil, fmt.Errorf("invalid ssz-type tag for '%v' field: %v", field.Name, sszTypeStr)
@@ -118,11 +166,14 @@ func (d *DynSsz) getSszTypeTag(field *reflect.StructField) ([]SszTypeHint, error
 //   - custom: A boolean indicating whether a non-default specification value has been applied to the type or field, typically through
 //     'dynssz-size' annotations, suggesting a deviation from standard size expectations that might influence the encoding or decoding process.
 //   - expr: The dynamic expression used to calculate the size of the field, typically through 'dynssz-size' annotations.
+//   - bits: The length in bits for bitvectors, as specified by 'ssz-bitsize' or 'dynssz-bitsize' tag annotations. The size is
+//     set to the number of bytes required to hold the bits.
 type SszSizeHint struct {
 	Size    uint32
 	Dynamic bool
 	Custom  bool
 	Expr    string
+	Bits    uint32
 }
 
 // getSszSizeTag parses the 'ssz-size' and 'dynssz-size' tag annotations from a struct field and returns size hints
@@ -189,11 +240,13 @@ func (d *DynSsz) getSszSizeTag(field *reflect.StructField) ([]SszSizeHint, error
 					// dynamic value from spec
 					sszSize.Size = uint32(specVal)
 					sszSize.Custom = true
+				} else if d.StrictSpecValues {
+					return sszSizes, fmt.Errorf("error parsing dynssz-size tag for '%v' field: %v", field.Name, d.getUnresolvedSpecValueError(sszSizeStr))
+				} else if i >= len(sszSizes) {
+					return sszSizes, fmt.Errorf("error parsing dynssz-size tag for '%v' field: %v (no ssz-size fallback)", field.Name, d.getUnresolvedSpecValueError(sszSizeStr))
 				} else {
 					// unknown spec value? fallback to fastssz defaults
-					if i < len(sszSizes) {
-						sszSizes[i].Expr = sszSizeStr
-					}
+					sszSizes[i].Expr = sszSizeStr
 					break
 				}
 			}
@@ -211,6 +264,55 @@ func (d *DynSsz) getSszSizeTag(field *reflect.StructField) ([]SszSizeHint, error
 		}
 	}
 
+	// parse `ssz-bitsize` and `dynssz-bitsize`, the bit length of the outermost bitvector dimension
+	bitSize := SszSizeHint{}
+	if fieldSszBitSizeStr, fieldHasSszBitSize := field.Tag.Lookup("ssz-bitsize"); fieldHasSszBitSize {
+		bitSizeInt, err := strconv.ParseUint(fieldSszBitSizeStr, 10, 32)
+		if err != nil {
+			return sszSizes, fmt.Errorf("error parsing ssz-bitsize tag for '%v' field: %v", field.Name, err)
+		}
+		bitSize.Bits = uint32(bitSizeInt)
+	}
+
+	if fieldDynSszBitSizeStr, fieldHasDynSszBitSize := field.Tag.Lookup("dynssz-bitsize"); fieldHasDynSszBitSize {
+		if bitSizeInt, err := strconv.ParseUint(fieldDynSszBitSizeStr, 10, 32); err == nil {
+			bitSize.Bits = uint32(bitSizeInt)
+		} else {
+			ok, specVal, err := d.ResolveSpecValue(fieldDynSszBitSizeStr)
+			if err != nil {
+				return sszSizes, fmt.Errorf("error parsing dynssz-bitsize tag for '%v' field (%v): %v", field.Name, fieldDynSszBitSizeStr, err)
+			}
+
+			if !ok && d.StrictSpecValues {
+				return sszSizes, fmt.Errorf("error parsing dynssz-bitsize tag for '%v' field: %v", field.Name, d.getUnresolvedSpecValueError(fieldDynSszBitSizeStr))
+			}
+			if !ok && bitSize.Bits == 0 {
+				return sszSizes, fmt.Errorf("error parsing dynssz-bitsize tag for '%v' field: %v (no ssz-bitsize fallback)", field.Name, d.getUnresolvedSpecValueError(fieldDynSszBitSizeStr))
+			}
+
+			bitSize.Expr = fieldDynSszBitSizeStr
+			if ok && uint32(specVal) != bitSize.Bits {
+				// dynamic value from spec
+				bitSize.Bits = uint32(specVal)
+				bitSize.Custom = true
+			}
+		}
+	}
+
+	if bitSize.Bits > 0 {
+		bitSize.Size = (bitSize.Bits + 7) / 8
+		if len(sszSizes) == 0 {
+			sszSizes = append(sszSizes, bitSize)
+		} else {
+			// an explicit byte size must match the bit size, unless the bit size is resolved from the spec
+			// and the byte size is just the static default
+			if !sszSizes[0].Dynamic && sszSizes[0].Size != bitSize.Size && (sszSizes[0].Custom || !bitSize.Custom) {
+				return sszSizes, fmt.Errorf("conflicting size tags for '%v' field: %d bits do not match %d bytes", field.Name, bitSize.Bits, sszSizes[0].Size)
+			}
+			sszSizes[0] = bitSize
+		}
+	}
+
 	return sszSizes, nil
 }
 
@@ -264,11 +366,13 @@ func (d *DynSsz) getSszMaxSizeTag(field *reflect.StructField) ([]SszMaxSizeHint,
 					// dynamic value from spec
 					sszMaxSize.Size = specVal
 					sszMaxSize.Custom = true
+				} else if d.StrictSpecValues {
+					return sszMaxSizes, fmt.Errorf("error parsing dynssz-max tag for '%v' field: %v", field.Name, d.getUnresolvedSpecValueError(sszMaxSizeStr))
+				} else if i >= len(sszMaxSizes) {
+					return sszMaxSizes, fmt.Errorf("error parsing dynssz-max tag for '%v' field: %v (no ssz-max fallback)", field.Name, d.getUnresolvedSpecValueError(sszMaxSizeStr))
 				} else {
 					// unknown spec value? fallback to fastssz defaults
-					if i < len(sszMaxSizes) {
-						sszMaxSizes[i].Expr = sszMaxSizeStr
-					}
+					sszMaxSizes[i].Expr = sszMaxSizeStr
 					continue
 				}
 			}
@@ -8,4 +8,6 @@ var (
 	ErrOffset              = fmt.Errorf("incorrect offset")
 	ErrInvalidUnionVariant = fmt.Errorf("invalid union variant")
 	ErrVectorLength        = fmt.Errorf("incorrect vector length")
+	ErrBitvectorPadding    = fmt.Errorf("bitvector padding bits are not zero")
+	ErrGindexOverflow      = fmt.Errorf("generalized index exceeds 64 bits")
 )
@@ -31,6 +31,28 @@ type DynamicSizer interface {
 	SizeSSZDyn(ds interface{}) int
 }
 
+// BitlistMarker is the interface implemented by byte slice types that should be handled as ssz bitlists.
+// The method carries no behaviour, it only marks the type, so no ssz-type:"bitlist" tag is required on fields.
+type BitlistMarker interface {
+	SszBitlist()
+}
+
+// LargeUintMarker is the interface implemented by fixed-width little endian integer types that should be handled as
+// ssz uint128 or uint256. The type must be represented by a [16]byte / [2]uint64 (uint128) or a [32]byte / [4]uint64 (uint256) array.
+// SszUintBits returns the bit width of the type (128 or 256) and is called on the zero value of the type.
+type LargeUintMarker interface {
+	SszUintBits() int
+}
+
+// StableContainerMarker is the interface implemented by struct types that should be handled as ssz stable containers (EIP-7495).
+// SszStableCapacity returns the capacity of the stable container and is called on the zero value of the type.
+// It replaces the ssz-type:"stable-container" and ssz-size tags, so the type can also be used as top-level value.
+type StableContainerMarker interface {
+	SszStableCapacity() int
+}
+
+// DynamicHashRoot is the interface implemented by types that hash themselves into the hasher of the parent value.
+// The implementation must leave exactly one 32 byte root in the hasher, e.g. by merkleizing its chunks from hh.Index().
 type DynamicHashRoot interface {
 	HashTreeRootDyn(ds interface{}, hh HashWalker) error
 }
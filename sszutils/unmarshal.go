@@ -24,6 +24,20 @@ func UnmarshallUint8(src []byte) uint8 {
 	return uint8(src[0])
 }
 
+// ValidateBitvectorPadding checks that the unused high bits of the last byte of a bitvector with the given bit length are zero
+func ValidateBitvectorPadding(bitvector []byte, bitSize uint64) error {
+	byteLen := (bitSize + 7) / 8
+	if bitSize%8 == 0 || uint64(len(bitvector)) < byteLen {
+		return nil
+	}
+
+	if bitvector[byteLen-1]>>(bitSize%8) != 0 {
+		return ErrBitvectorPadding
+	}
+
+	return nil
+}
+
 // UnmarshalBool unmarshals a boolean from the src input
 func UnmarshalBool(src []byte) bool {
 	return src[0] == 1
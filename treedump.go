@@ -0,0 +1,270 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// TreeChunk is a 32 byte node of a hash tree.
+// It is rendered as 0x prefixed hex string in the text and JSON output of a TreeNode.
+type TreeChunk [32]byte
+
+// String returns the chunk as 0x prefixed hex string.
+func (c TreeChunk) String() string {
+	return fmt.Sprintf("0x%x", c[:])
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (c TreeChunk) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// TreeNode describes a value within the hash tree of an object, as returned by DumpTree.
+//
+// Composite values list the chunks that are merkleized into their root, values that contribute
+// their own root (fields, elements of non-basic types, union & optional values) are listed as children.
+// Elements of basic types are packed into the chunks and do not have a node on their own.
+type TreeNode struct {
+	Name             string      `json:"name"`               // Field name or element index, empty for the root value
+	Type             string      `json:"type"`               // Go type of the value
+	Kind             string      `json:"kind"`               // SSZ type of the value
+	GeneralizedIndex uint64      `json:"gindex"`             // Generalized index of the value in the tree of the root value
+	Root             TreeChunk   `json:"root"`               // Hash tree root of the value
+	Chunks           []TreeChunk `json:"chunks,omitempty"`   // Chunks merkleized into the root (composite values only)
+	Mixin            *TreeChunk  `json:"mixin,omitempty"`    // Chunk mixed into the content root (length, selector or active fields)
+	Children         []*TreeNode `json:"children,omitempty"` // Nested values that contribute their own root
+}
+
+// String renders the tree as indented text, one line per node, chunk and mixin.
+func (n *TreeNode) String() string {
+	var out strings.Builder
+	n.writeText(&out, 0)
+	return out.String()
+}
+
+// JSON renders the tree as indented JSON.
+func (n *TreeNode) JSON() ([]byte, error) {
+	return json.MarshalIndent(n, "", "  ")
+}
+
+// writeText appends the text representation of the node and its children to out.
+func (n *TreeNode) writeText(out *strings.Builder, idt int) {
+	indent := strings.Repeat(" ", idt)
+
+	name := n.Name
+	if name == "" {
+		name = "<root>"
+	}
+	fmt.Fprintf(out, "%v%v (%v, %v) gindex: %v root: %v\n", indent, name, n.Type, n.Kind, n.GeneralizedIndex, n.Root)
+
+	for i, chunk := range n.Chunks {
+		fmt.Fprintf(out, "%v  chunk %v: %v\n", indent, i, chunk)
+	}
+	if n.Mixin != nil {
+		fmt.Fprintf(out, "%v  mixin: %v\n", indent, *n.Mixin)
+	}
+
+	for _, child := range n.Children {
+		child.writeText(out, idt+2)
+	}
+}
+
+// DumpTree returns the structure of the hash tree of the given source object.
+//
+// The chunks are produced by the proof chunk walker (getMerkleChunks) that also builds the branches of
+// GetProof. The roots of the nodes are computed by the buildRootFromType traversal used by HashTreeRoot,
+// and the chunks of every node are checked to merkleize to that root. If the proof chunks diverge from the
+// hashing path, an error naming the diverging value is returned instead of a tree. The dump makes it
+// possible to compare the trees of two implementations node by node to find where their roots diverge.
+//
+// Pointers, type wrappers and maps do not add a level to the hash tree, so they are described by the
+// node of the value they resolve to. Unions and optional values have a single child for the contained
+// value, named "Data" and "Value". Types with custom hashing are not descended into.
+//
+// As every node hashes its own subtree, dumping is considerably slower than HashTreeRoot and intended
+// for debugging only.
+//
+// Parameters:
+//   - source: The Go value to dump the hash tree for
+//
+// Returns:
+//   - *TreeNode: The root node of the tree
+//   - error: An error if the type is not supported, hashing fails or the chunks do not match the hash tree root
+//
+// Example:
+//
+//	tree, err := ds.DumpTree(block)
+//	if err != nil {
+//	    log.Fatal("Failed to dump tree:", err)
+//	}
+//	fmt.Println(tree.String())
+func (d *DynSsz) DumpTree(source any) (*TreeNode, error) {
+	sourceType := reflect.TypeOf(source)
+	sourceValue := reflect.ValueOf(source)
+
+	sourceTypeDesc, err := d.typeCache.GetTypeDescriptor(sourceType, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := d.getValueRoot(sourceTypeDesc, sourceValue)
+	if err != nil {
+		return nil, wrapRootError(err, sourceType)
+	}
+
+	node := &TreeNode{
+		GeneralizedIndex: 1,
+		Root:             root,
+	}
+
+	err = d.dumpTreeFromType(sourceTypeDesc, sourceValue, node)
+	if err != nil {
+		return nil, wrapRootError(err, sourceType)
+	}
+
+	return node, nil
+}
+
+// dumpTreeFromType fills the node of a value with its type information, chunks and children.
+// The root and generalized index of the node are set by the caller.
+func (d *DynSsz) dumpTreeFromType(sourceType *TypeDescriptor, sourceValue reflect.Value, node *TreeNode) error {
+	node.Type = sourceType.Type.String()
+
+	sourceType, sourceValue = d.unwrapProofValue(sourceType, sourceValue)
+	node.Kind = sourceType.SszType.String()
+
+	if sourceType.SszType == SszCustomType || sourceType.SszCompatFlags&SszCompatFlagDynamicHashRoot != 0 {
+		return nil
+	}
+
+	switch sourceType.SszType {
+	case SszContainerType, SszProgressiveContainerType, SszStableContainerType, SszCompatibleUnionType, SszUnionType, SszOptionalType:
+	case SszVectorType, SszBitvectorType, SszListType, SszBitlistType, SszProgressiveListType, SszProgressiveBitlistType:
+	default:
+		// basic types are leafs
+		return nil
+	}
+
+	chunks, mixin, err := d.getMerkleChunks(sourceType, sourceValue)
+	if err != nil {
+		return err
+	}
+
+	layout, err := d.getMerkleLayout(sourceType, sourceValue)
+	if err != nil {
+		return err
+	}
+
+	// the chunks must merkleize to the root computed by the hashing path
+	if root := TreeChunk(layout.getRoot(chunks, mixin)); root != node.Root {
+		return fmt.Errorf("tree dump of %v diverges from hash tree root: chunks merkleize to %v, expected %v", sourceType.Type, root, node.Root)
+	}
+
+	node.Chunks = make([]TreeChunk, len(chunks))
+	for i, chunk := range chunks {
+		node.Chunks[i] = TreeChunk(chunk)
+	}
+	if layout.mixin {
+		mixinChunk := TreeChunk(mixin)
+		node.Mixin = &mixinChunk
+	}
+
+	addChild := func(name string, chunkIndex uint64, childType *TypeDescriptor, childValue reflect.Value) error {
+		gindex, err := layout.getChunkGeneralizedIndex(chunkIndex)
+		if err != nil {
+			return err
+		}
+		gindex, err = concatGeneralizedIndex(node.GeneralizedIndex, gindex)
+		if err != nil {
+			return err
+		}
+
+		child := &TreeNode{
+			Name:             name,
+			GeneralizedIndex: gindex,
+			Root:             chunks[chunkIndex],
+		}
+		if err := d.dumpTreeFromType(childType, childValue, child); err != nil {
+			return err
+		}
+
+		node.Children = append(node.Children, child)
+		return nil
+	}
+
+	switch sourceType.SszType {
+	case SszContainerType, SszProgressiveContainerType, SszStableContainerType:
+		for i, field := range sourceType.ContainerDesc.Fields {
+			fieldValue := sourceValue.Field(i)
+			if sourceType.SszType == SszStableContainerType && fieldValue.IsNil() {
+				// absent fields are zero chunks
+				continue
+			}
+
+			chunkIndex := uint64(i)
+			if sourceType.SszType == SszProgressiveContainerType {
+				chunkIndex = uint64(field.SszIndex)
+			}
+
+			if err := addChild(field.Name, chunkIndex, field.Type, fieldValue); err != nil {
+				return wrapFieldError(err, field.Name)
+			}
+		}
+
+	case SszCompatibleUnionType, SszUnionType:
+		selector := uint8(sourceValue.Field(0).Uint())
+		variantDesc := sourceType.UnionVariants[selector]
+		if variantDesc == nil {
+			// None option of a union
+			return nil
+		}
+
+		dataValue, err := getUnionVariantValue(variantDesc, selector, sourceValue.Field(1))
+		if err != nil {
+			return err
+		}
+
+		if err := addChild("Data", 0, variantDesc, dataValue); err != nil {
+			return wrapFieldError(err, "Data")
+		}
+
+	case SszOptionalType:
+		if sourceValue.IsNil() {
+			return nil
+		}
+
+		if err := addChild("Value", 0, sourceType.ElemDesc, sourceValue.Elem()); err != nil {
+			return err
+		}
+
+	case SszVectorType, SszListType, SszProgressiveListType:
+		if getPackedItemSize(sourceType.ElemDesc) > 0 {
+			// basic elements are packed into the chunks
+			return nil
+		}
+
+		itemCount := sourceValue.Len()
+		for i := range chunks {
+			var elemValue reflect.Value
+			if i < itemCount {
+				elemValue = sourceValue.Index(i)
+			} else if sourceType.ElemDesc.GoTypeFlags&GoTypeFlagIsPointer != 0 {
+				elemValue = reflect.New(sourceType.ElemDesc.Type.Elem())
+			} else {
+				elemValue = reflect.New(sourceType.ElemDesc.Type).Elem()
+			}
+
+			if err := addChild(strconv.Itoa(i), uint64(i), sourceType.ElemDesc, elemValue); err != nil {
+				return wrapIndexError(err, i)
+			}
+		}
+	}
+
+	return nil
+}
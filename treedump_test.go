@@ -0,0 +1,217 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+// findTreeNode descends into the tree by the given child names.
+func findTreeNode(node *TreeNode, names ...string) *TreeNode {
+	for _, name := range names {
+		var next *TreeNode
+		for _, child := range node.Children {
+			if child.Name == name {
+				next = child
+				break
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		node = next
+	}
+
+	return node
+}
+
+func TestDumpTree(t *testing.T) {
+	payload := &proofTestContainer{
+		Slot:     1337,
+		Balances: []uint64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+		Elements: []*proofTestElement{
+			{Index: 1, Data: []byte{1, 2, 3}},
+			nil,
+		},
+		Bits:        []byte{0x01},
+		Progressive: []uint32{1, 2, 3},
+		Container: proofTestProgressive{
+			Field0: 42,
+		},
+		Optional: &proofTestElement{Index: 3},
+		Stable: proofTestStable{
+			B: &proofTestElement{Index: 12},
+		},
+		Map: map[uint64]*proofTestElement{
+			7: {Index: 7},
+		},
+	}
+	payload.Union.Variant = 0
+	payload.Union.Data = proofTestElement{Index: 9, Data: []byte{9}}
+	payload.Maybe.Selector = 1
+	payload.Maybe.Data = proofTestElement{Index: 10, Data: []byte{10}}
+
+	ds := NewDynSsz(nil)
+
+	tree, err := ds.DumpTree(payload)
+	if err != nil {
+		t.Fatalf("failed to dump tree: %v", err)
+	}
+
+	root, err := ds.HashTreeRoot(payload)
+	if err != nil {
+		t.Fatalf("failed to hash payload: %v", err)
+	}
+	if tree.Root != TreeChunk(root) {
+		t.Errorf("root mismatch: got %v, wanted 0x%x", tree.Root, root)
+	}
+	if tree.Kind != "container" || tree.Type != "*dynssz_test.proofTestContainer" {
+		t.Errorf("unexpected root node type: %v (%v)", tree.Type, tree.Kind)
+	}
+	if len(tree.Children) != 14 || len(tree.Chunks) != 14 {
+		t.Errorf("expected 14 children and chunks, got %v and %v", len(tree.Children), len(tree.Chunks))
+	}
+
+	// the nodes match the leafs of the proofs for the same paths
+	testCases := []struct {
+		names []string
+		path  []string
+	}{
+		{[]string{"Slot"}, []string{"Slot"}},
+		{[]string{"Balances"}, []string{"Balances"}},
+		{[]string{"Elements", "0", "Data"}, []string{"Elements", "0", "Data"}},
+		{[]string{"Elements", "1", "Index"}, []string{"Elements", "1", "Index"}},
+		{[]string{"Fixed", "4", "Data"}, []string{"Fixed", "4", "Data"}},
+		{[]string{"Container", "Field1"}, []string{"Container", "Field1"}},
+		{[]string{"Union"}, []string{"Union"}},
+		{[]string{"Union", "Data", "Data"}, []string{"Union", "Data"}},
+		{[]string{"Optional", "Value", "Index"}, []string{"Optional", "Index"}},
+		{[]string{"Stable", "B", "Index"}, []string{"Stable", "B", "Index"}},
+		{[]string{"Map", "0", "Value", "Index"}, []string{"Map", "0", "Value", "Index"}},
+		{[]string{"Maybe", "Data", "Data"}, []string{"Maybe", "Data"}},
+	}
+
+	for _, tc := range testCases {
+		node := findTreeNode(tree, tc.names...)
+		if node == nil {
+			t.Errorf("node %v not found", tc.names)
+			continue
+		}
+
+		proof, err := ds.GetProof(payload, tc.path)
+		if err != nil {
+			t.Errorf("path %v: unexpected error: %v", tc.path, err)
+			continue
+		}
+		if node.Root != TreeChunk(proof.Leaf) {
+			t.Errorf("node %v: root mismatch: got %v, wanted 0x%x", tc.names, node.Root, proof.Leaf)
+		}
+		if node.GeneralizedIndex != proof.GeneralizedIndex {
+			t.Errorf("node %v: generalized index mismatch: got %v, wanted %v", tc.names, node.GeneralizedIndex, proof.GeneralizedIndex)
+		}
+	}
+
+	// basic elements are packed into the chunks, the length is mixed in
+	balances := findTreeNode(tree, "Balances")
+	if len(balances.Children) != 0 || len(balances.Chunks) != 3 {
+		t.Errorf("expected 3 packed chunks without children, got %v chunks and %v children", len(balances.Chunks), len(balances.Children))
+	} else if balances.Chunks[0] != (TreeChunk{0: 1, 8: 2, 16: 3, 24: 4}) {
+		t.Errorf("unexpected first balances chunk: %v", balances.Chunks[0])
+	}
+	if balances.Mixin == nil || *balances.Mixin != (TreeChunk{10}) {
+		t.Errorf("expected length mixin 10, got %v", balances.Mixin)
+	}
+
+	// both union kinds have distinct names
+	if node := findTreeNode(tree, "Union"); node == nil || node.Kind != "compatible-union" {
+		t.Errorf("expected compatible-union kind for Union, got %v", node)
+	}
+	if node := findTreeNode(tree, "Maybe"); node == nil || node.Kind != "ssz-union" {
+		t.Errorf("expected ssz-union kind for Maybe, got %v", node)
+	}
+
+	// absent fields of stable containers have no node
+	if node := findTreeNode(tree, "Stable", "A"); node != nil {
+		t.Errorf("expected no node for absent stable container field")
+	}
+
+	text := tree.String()
+	if !strings.HasPrefix(text, "<root> (*dynssz_test.proofTestContainer, container) gindex: 1 root: "+tree.Root.String()) {
+		t.Errorf("unexpected text output: %v", strings.SplitN(text, "\n", 2)[0])
+	}
+	if !contains(text, "\n  Slot (uint64, uint64) gindex: 16 root: 0x3905") {
+		t.Errorf("expected Slot line in text output")
+	}
+
+	jsonData, err := tree.JSON()
+	if err != nil {
+		t.Fatalf("failed to render json: %v", err)
+	}
+
+	var decoded struct {
+		Root     string `json:"root"`
+		Children []struct {
+			Name string `json:"name"`
+			Kind string `json:"kind"`
+		} `json:"children"`
+	}
+	if err := json.Unmarshal(jsonData, &decoded); err != nil {
+		t.Fatalf("failed to parse json: %v", err)
+	}
+	if decoded.Root != tree.Root.String() {
+		t.Errorf("json root mismatch: got %v, wanted %v", decoded.Root, tree.Root)
+	}
+	if len(decoded.Children) != 14 || decoded.Children[2].Name != "Balances" || decoded.Children[2].Kind != "list" {
+		t.Errorf("unexpected json children: %v", decoded.Children)
+	}
+}
+
+func TestDumpTreeErrors(t *testing.T) {
+	ds := NewDynSsz(nil)
+
+	_, err := ds.DumpTree(struct {
+		Values []uint64 `ssz-size:"2"`
+	}{
+		Values: []uint64{1, 2, 3},
+	})
+	if err == nil || !contains(err.Error(), "Values") || !contains(err.Error(), "list length is higher than max value") {
+		t.Errorf("expected vector overflow error, got %v", err)
+	}
+}
+
+// treeDumpInconsistentHasher hashes to a root that does not match its fields.
+type treeDumpInconsistentHasher struct {
+	Value uint64
+}
+
+func (h *treeDumpInconsistentHasher) HashTreeRoot() ([32]byte, error) {
+	return [32]byte{0xff}, nil
+}
+
+func TestDumpTreeRootMismatch(t *testing.T) {
+	ds := NewDynSsz(nil)
+
+	_, err := ds.DumpTree(&treeDumpInconsistentHasher{Value: 1})
+	if err == nil || !contains(err.Error(), "diverges from hash tree root") {
+		t.Errorf("expected root mismatch error, got %v", err)
+	}
+
+	_, err = ds.DumpTree(&struct {
+		Slot  uint64
+		Inner treeDumpInconsistentHasher
+	}{Slot: 1})
+	if err == nil || !contains(err.Error(), "Inner") || !contains(err.Error(), "diverges from hash tree root") {
+		t.Errorf("expected root mismatch error for nested value, got %v", err)
+	}
+
+	// without the fastssz hasher both paths walk the fields
+	ds.NoFastSsz = true
+	if _, err := ds.DumpTree(&treeDumpInconsistentHasher{Value: 1}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -6,12 +6,29 @@ package dynssz
 import (
 	"fmt"
 	"reflect"
-	"strings"
+	"sync"
+	"unsafe"
 
 	"github.com/pk910/dynamic-ssz/hasher"
 	"github.com/pk910/dynamic-ssz/sszutils"
 )
 
+// parallelHashMinItems is the minimum number of container elements a list or vector must hold
+// before its element roots are computed in parallel. Smaller slices are hashed serially as the
+// goroutine overhead outweighs the gain.
+const parallelHashMinItems = 256
+
+// parallelHashMinItemsPerWorker limits the number of workers for slices slightly above the threshold.
+const parallelHashMinItemsPerWorker = 64
+
+// getHasherPool returns the hasher pool to use for hash tree root calculations.
+func (d *DynSsz) getHasherPool() *hasher.HasherPool {
+	if d.NoFastHash {
+		return &hasher.DefaultHasherPool
+	}
+	return &hasher.FastHasherPool
+}
+
 // buildRootFromType is the core recursive function for computing hash tree roots of Go values.
 //
 // This function serves as the primary dispatcher within the hashing process, handling both
@@ -38,6 +55,20 @@ import (
 func (d *DynSsz) buildRootFromType(sourceType *TypeDescriptor, sourceValue reflect.Value, hh *hasher.Hasher, pack bool, idt int) error {
 	hashIndex := hh.Index()
 
+	// the root cache is keyed by pointer identity, so only non-nil pointers can be cached
+	var cachePtr unsafe.Pointer
+	if d.RootCache != nil && !pack && sourceType.GoTypeFlags&GoTypeFlagIsPointer != 0 && !sourceValue.IsNil() {
+		cachePtr = sourceValue.UnsafePointer()
+		if root, ok := d.RootCache.getRoot(cachePtr, sourceType); ok {
+			if d.logEnabled() {
+				d.log(idt, "cached hash", "hash", fmt.Sprintf("0x%x", root))
+			}
+
+			hh.PutBytes(root[:])
+			return nil
+		}
+	}
+
 	if sourceType.GoTypeFlags&GoTypeFlagIsPointer != 0 {
 		if sourceValue.IsNil() {
 			sourceValue = reflect.New(sourceType.Type.Elem()).Elem()
@@ -55,8 +86,8 @@ func (d *DynSsz) buildRootFromType(sourceType *TypeDescriptor, sourceValue refle
 		useFastSsz = true
 	}
 
-	if d.Verbose {
-		fmt.Printf("%stype: %s\t kind: %v\t fastssz: %v (compat: %v/ dynamic: %v/%v)\t index: %v\n", strings.Repeat(" ", idt), sourceType.Type.Name(), sourceType.Kind, useFastSsz, isFastsszHasher, hasDynamicSize, hasDynamicMax, hashIndex)
+	if d.logEnabled() {
+		d.log(idt, "hash type", "type", sourceType.Type.Name(), "kind", sourceType.Kind, "fastssz", useFastSsz, "compat", isFastsszHasher, "dynamicSize", hasDynamicSize, "dynamicMax", hasDynamicMax, "index", hashIndex)
 	}
 
 	if useFastSsz {
@@ -85,11 +116,23 @@ func (d *DynSsz) buildRootFromType(sourceType *TypeDescriptor, sourceValue refle
 
 	if !useFastSsz && useDynamicHashRoot {
 		// Use dynamic hash root - can always be used even with dynamic specs
+		if !sourceValue.CanAddr() {
+			// values passed by value (or nested in them) are not addressable, use a copy for the pointer receiver
+			valueCopy := reflect.New(sourceValue.Type()).Elem()
+			valueCopy.Set(sourceValue)
+			sourceValue = valueCopy
+		}
+
 		hasher, ok := sourceValue.Addr().Interface().(sszutils.DynamicHashRoot)
 		if ok {
 			err := hasher.HashTreeRootDyn(d, hh)
 			if err != nil {
-				return fmt.Errorf("failed HashTreeRootDyn: %v", err)
+				return fmt.Errorf("failed HashTreeRootDyn: %w", err)
+			}
+
+			// the parent merkleizes all chunks from its own index, so the hook must leave exactly one root behind
+			if !pack && hh.Index() != hashIndex+32 {
+				return fmt.Errorf("HashTreeRootDyn of %v must produce exactly one 32 byte root, got %v bytes", sourceType.Type, hh.Index()-hashIndex)
 			}
 		} else {
 			useDynamicHashRoot = false
@@ -129,11 +172,31 @@ func (d *DynSsz) buildRootFromType(sourceType *TypeDescriptor, sourceValue refle
 			if err != nil {
 				return err
 			}
+		case SszUnionType:
+			err := d.buildRootFromUnion(sourceType, sourceValue, hh, idt)
+			if err != nil {
+				return err
+			}
 		case SszCompatibleUnionType:
 			err := d.buildRootFromCompatibleUnion(sourceType, sourceValue, hh, idt)
 			if err != nil {
 				return err
 			}
+		case SszOptionalType:
+			err := d.buildRootFromOptional(sourceType, sourceValue, hh, idt)
+			if err != nil {
+				return err
+			}
+		case SszStableContainerType:
+			err := d.buildRootFromStableContainer(sourceType, sourceValue, hh, idt)
+			if err != nil {
+				return err
+			}
+		case SszMapType:
+			err := d.buildRootFromList(sourceType.ElemDesc, getMapEntries(sourceType, sourceValue), hh, idt)
+			if err != nil {
+				return err
+			}
 
 		case SszBoolType:
 			if pack {
@@ -175,8 +238,12 @@ func (d *DynSsz) buildRootFromType(sourceType *TypeDescriptor, sourceValue refle
 		}
 	}
 
-	if d.Verbose {
-		fmt.Printf("%shash: 0x%x\n", strings.Repeat(" ", idt), hh.Hash())
+	if d.logEnabled() {
+		d.log(idt, "hash", "type", sourceType.Type.Name(), "hash", fmt.Sprintf("0x%x", hh.Hash()))
+	}
+
+	if cachePtr != nil && hh.Index() == hashIndex+32 {
+		d.RootCache.setRoot(cachePtr, sourceType, hh.Hash())
 	}
 
 	return nil
@@ -197,8 +264,8 @@ func (d *DynSsz) buildRootFromType(sourceType *TypeDescriptor, sourceValue refle
 // The function extracts the Data field from the TypeWrapper and builds the hash tree root for the wrapped value using its type descriptor.
 
 func (d *DynSsz) buildRootFromTypeWrapper(sourceType *TypeDescriptor, sourceValue reflect.Value, hh *hasher.Hasher, pack bool, idt int) error {
-	if d.Verbose {
-		fmt.Printf("%sbuildRootFromTypeWrapper: %s\n", strings.Repeat(" ", idt), sourceType.Type.Name())
+	if d.logEnabled() {
+		d.log(idt, "buildRootFromTypeWrapper", "type", sourceType.Type.Name())
 	}
 
 	// Extract the Data field from the TypeWrapper
@@ -289,13 +356,13 @@ func (d *DynSsz) buildRootFromContainer(sourceType *TypeDescriptor, sourceValue
 		fieldType := field.Type
 		fieldValue := sourceValue.Field(i)
 
-		if d.Verbose {
-			fmt.Printf("%sfield %v\n", strings.Repeat(" ", idt), field.Name)
+		if d.logEnabled() {
+			d.log(idt, "field", "name", field.Name)
 		}
 
 		err := d.buildRootFromType(fieldType, fieldValue, hh, false, idt+2)
 		if err != nil {
-			return err
+			return wrapFieldError(err, field.Name)
 		}
 	}
 
@@ -347,13 +414,13 @@ func (d *DynSsz) buildRootFromProgressiveContainer(sourceType *TypeDescriptor, s
 		fieldType := field.Type
 		fieldValue := sourceValue.Field(i)
 
-		if d.Verbose {
-			fmt.Printf("%sfield %v\n", strings.Repeat(" ", idt), field.Name)
+		if d.logEnabled() {
+			d.log(idt, "field", "name", field.Name)
 		}
 
 		err := d.buildRootFromType(fieldType, fieldValue, hh, false, idt+2)
 		if err != nil {
-			return err
+			return wrapFieldError(err, field.Name)
 		}
 	}
 
@@ -396,13 +463,16 @@ func (d *DynSsz) buildRootFromCompatibleUnion(sourceType *TypeDescriptor, source
 	if dataField.IsNil() {
 		return sszutils.ErrInvalidUnionVariant
 	}
-	dataField = dataField.Elem()
+	dataValue, err := getUnionVariantValue(variantDesc, variant, dataField)
+	if err != nil {
+		return err
+	}
 
 	hashIndex := hh.Index()
 
-	err := d.buildRootFromType(variantDesc, dataField, hh, false, idt+2)
+	err = d.buildRootFromType(variantDesc, dataValue, hh, false, idt+2)
 	if err != nil {
-		return fmt.Errorf("failed to hash union variant %d: %w", variant, err)
+		return wrapFieldError(err, "Data")
 	}
 
 	// mixin the selector
@@ -414,6 +484,138 @@ func (d *DynSsz) buildRootFromCompatibleUnion(sourceType *TypeDescriptor, source
 	return nil
 }
 
+// buildRootFromUnion computes the hash tree root for Union values.
+//
+// According to the spec:
+// - mix_in_selector(hash_tree_root(value.value), value.selector)
+// - The None option hashes to mix_in_selector(Bytes32(), 0)
+//
+// Parameters:
+//   - sourceType: The TypeDescriptor containing union metadata and variant descriptors
+//   - sourceValue: The reflect.Value of the Union to hash
+//   - hh: The Hasher instance for hash computation
+//   - idt: Indentation level for verbose logging
+//
+// Returns:
+//   - error: An error if hashing fails
+func (d *DynSsz) buildRootFromUnion(sourceType *TypeDescriptor, sourceValue reflect.Value, hh *hasher.Hasher, idt int) error {
+	// Union has exactly 2 fields: Selector (uint8) and Data (interface{})
+	selector := uint8(sourceValue.Field(0).Uint())
+	dataField := sourceValue.Field(1)
+
+	variantDesc, ok := sourceType.UnionVariants[selector]
+	if !ok {
+		return sszutils.ErrInvalidUnionVariant
+	}
+
+	hashIndex := hh.Index()
+
+	if variantDesc == nil {
+		// None option, zero root
+		var zeroRoot [32]byte
+		hh.PutBytes(zeroRoot[:])
+	} else {
+		dataValue, err := getUnionVariantValue(variantDesc, selector, dataField)
+		if err != nil {
+			return err
+		}
+
+		err = d.buildRootFromType(variantDesc, dataValue, hh, false, idt+2)
+		if err != nil {
+			return wrapFieldError(err, "Data")
+		}
+	}
+
+	// mixin the selector
+	hh.MerkleizeWithMixin(hashIndex, uint64(selector), 1)
+
+	return nil
+}
+
+// buildRootFromStableContainer computes the hash tree root for ssz stable containers (EIP-7495).
+//
+// Stable containers are hashed as:
+//   - The field roots are merkleized with the container capacity as limit, absent fields contribute a zero chunk
+//   - The resulting root is mixed with the hash tree root of the active fields Bitvector[N]
+//
+// Parameters:
+//   - sourceType: The TypeDescriptor containing stable container metadata
+//   - sourceValue: The reflect.Value of the stable container to hash
+//   - hh: The Hasher instance for hash computation
+//   - idt: Indentation level for verbose logging
+//
+// Returns:
+//   - error: An error if hashing fails
+func (d *DynSsz) buildRootFromStableContainer(sourceType *TypeDescriptor, sourceValue reflect.Value, hh *hasher.Hasher, idt int) error {
+	hashIndex := hh.Index()
+
+	activeFields := make([]byte, (sourceType.Len+7)/8)
+	for i, field := range sourceType.ContainerDesc.Fields {
+		fieldValue := sourceValue.Field(i)
+		if fieldValue.IsNil() {
+			hh.PutUint8(0) // zero chunk for absent fields
+			continue
+		}
+
+		activeFields[i/8] |= 1 << (i % 8)
+
+		err := d.buildRootFromType(field.Type, fieldValue, hh, false, idt+2)
+		if err != nil {
+			return wrapFieldError(err, field.Name)
+		}
+	}
+
+	// pad with zero chunks up to the capacity
+	for i := len(sourceType.ContainerDesc.Fields); i < int(sourceType.Len); i++ {
+		hh.PutUint8(0)
+	}
+	hh.Merkleize(hashIndex)
+
+	// mixin the active fields bitvector root
+	bitvectorIndex := hh.Index()
+	hh.AppendBytes32(activeFields)
+	hh.Merkleize(bitvectorIndex)
+
+	hh.Merkleize(hashIndex)
+
+	return nil
+}
+
+// buildRootFromOptional computes the hash tree root for ssz optionals (EIP-6475).
+//
+// Optionals are hashed as:
+//   - Present value: mix_in_selector(hash_tree_root(value), 1)
+//   - Absent value (nil pointer): mix_in_selector(Bytes32(), 0)
+//
+// Parameters:
+//   - sourceType: The TypeDescriptor containing the optional's value descriptor
+//   - sourceValue: The reflect.Value of the optional pointer to hash
+//   - hh: The Hasher instance for hash computation
+//   - idt: Indentation level for verbose logging
+//
+// Returns:
+//   - error: An error if hashing fails
+func (d *DynSsz) buildRootFromOptional(sourceType *TypeDescriptor, sourceValue reflect.Value, hh *hasher.Hasher, idt int) error {
+	hashIndex := hh.Index()
+
+	if sourceValue.IsNil() {
+		hh.PutUint8(0)
+		hh.PutUint8(0)
+	} else {
+		err := d.buildRootFromType(sourceType.ElemDesc, sourceValue.Elem(), hh, false, idt+2)
+		if err != nil {
+			return err
+		}
+
+		hh.PutUint8(1)
+	}
+
+	// merkleize the value root with the selector
+	hh.Merkleize(hashIndex)
+
+	return nil
+}
+
 // buildRootFromVector computes the hash tree root for ssz vectors.
 //
 // Arrays in SSZ are hashed based on their element type:
@@ -440,8 +642,14 @@ func (d *DynSsz) buildRootFromVector(sourceType *TypeDescriptor, sourceValue ref
 	hashIndex := hh.Index()
 
 	sliceLen := sourceValue.Len()
-	if uint32(sliceLen) > sourceType.Len {
-		return sszutils.ErrListTooBig
+	if err := validateVectorLength(sourceType, sliceLen); err != nil {
+		return err
+	}
+
+	if sourceType.SszType == SszBitvectorType {
+		if err := validateBitvectorPadding(sourceType, sourceValue); err != nil {
+			return err
+		}
 	}
 
 	appendZero := 0
@@ -474,13 +682,20 @@ func (d *DynSsz) buildRootFromVector(sourceType *TypeDescriptor, sourceValue ref
 	} else {
 		// For other types, process each element
 		arrayLen := sourceValue.Len()
-		for i := 0; i < arrayLen; i++ {
-			fieldValue := sourceValue.Index(i)
-
-			err := d.buildRootFromType(sourceType.ElemDesc, fieldValue, hh, true, idt+2)
+		if d.useParallelHashing(sourceType.ElemDesc, arrayLen) {
+			err := d.buildRootFromElementsParallel(sourceType.ElemDesc, sourceValue, hh, idt)
 			if err != nil {
 				return err
 			}
+		} else {
+			for i := 0; i < arrayLen; i++ {
+				fieldValue := sourceValue.Index(i)
+
+				err := d.buildRootFromType(sourceType.ElemDesc, fieldValue, hh, true, idt+2)
+				if err != nil {
+					return wrapIndexError(err, i)
+				}
+			}
 		}
 
 		if appendZero > 0 {
@@ -543,6 +758,9 @@ func (d *DynSsz) buildRootFromList(sourceType *TypeDescriptor, sourceValue refle
 	hashIndex := hh.Index()
 
 	sliceLen := sourceValue.Len()
+	if err := d.validateListLimit(sourceType, sliceLen); err != nil {
+		return err
+	}
 
 	// For byte arrays, handle as a single unit
 	if sourceType.GoTypeFlags&GoTypeFlagIsByteArray != 0 {
@@ -564,13 +782,20 @@ func (d *DynSsz) buildRootFromList(sourceType *TypeDescriptor, sourceValue refle
 	} else {
 		// For other types, process each element
 		arrayLen := sourceValue.Len()
-		for i := 0; i < arrayLen; i++ {
-			fieldValue := sourceValue.Index(i)
-
-			err := d.buildRootFromType(sourceType.ElemDesc, fieldValue, hh, true, idt+2)
+		if d.useParallelHashing(sourceType.ElemDesc, arrayLen) {
+			err := d.buildRootFromElementsParallel(sourceType.ElemDesc, sourceValue, hh, idt)
 			if err != nil {
 				return err
 			}
+		} else {
+			for i := 0; i < arrayLen; i++ {
+				fieldValue := sourceValue.Index(i)
+
+				err := d.buildRootFromType(sourceType.ElemDesc, fieldValue, hh, true, idt+2)
+				if err != nil {
+					return wrapIndexError(err, i)
+				}
+			}
 		}
 
 		hh.FillUpTo32()
@@ -617,6 +842,89 @@ func (d *DynSsz) buildRootFromList(sourceType *TypeDescriptor, sourceValue refle
 	return nil
 }
 
+// useParallelHashing checks whether the elements of a list or vector should be hashed in parallel.
+//
+// Parallel hashing is only used for container elements, as each of them contributes exactly one
+// 32 byte root to the parent merkle tree. Packed basic types are always hashed serially.
+// Logging (Verbose or a Logger) forces the serial path to keep the log output in order.
+func (d *DynSsz) useParallelHashing(elemType *TypeDescriptor, itemCount int) bool {
+	if d.MaxConcurrency <= 1 || d.logEnabled() || itemCount < parallelHashMinItems {
+		return false
+	}
+
+	return elemType.SszType == SszContainerType || elemType.SszType == SszProgressiveContainerType
+}
+
+// buildRootFromElementsParallel computes the hash tree roots of all elements of a list or vector
+// using a pool of worker goroutines.
+//
+// The elements are split into contiguous ranges, one per worker. Each worker uses its own hasher
+// from the pool and writes the element roots into a shared result buffer at the element position.
+// The roots are appended to the parent hasher in order afterwards, so the final merkleization is
+// bit-for-bit identical to the serial path.
+//
+// Parameters:
+//   - elemType: The TypeDescriptor of the slice elements
+//   - sourceValue: The reflect.Value of the list or vector to hash
+//   - hh: The Hasher instance the element roots are appended to
+//   - idt: Indentation level for verbose logging
+//
+// Returns:
+//   - error: The error of the first failing element (in element order)
+
+func (d *DynSsz) buildRootFromElementsParallel(elemType *TypeDescriptor, sourceValue reflect.Value, hh *hasher.Hasher, idt int) error {
+	itemCount := sourceValue.Len()
+
+	workers := d.MaxConcurrency
+	if maxWorkers := itemCount / parallelHashMinItemsPerWorker; workers > maxWorkers {
+		workers = maxWorkers
+	}
+
+	itemsPerWorker := (itemCount + workers - 1) / workers
+	roots := make([]byte, itemCount*32)
+	workerErrs := make([]error, workers)
+	pool := d.getHasherPool()
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		start := w * itemsPerWorker
+		end := min(start+itemsPerWorker, itemCount)
+		if start >= end {
+			break
+		}
+
+		wg.Add(1)
+		go func(worker, start, end int) {
+			defer wg.Done()
+
+			whh := pool.Get()
+			defer pool.Put(whh)
+
+			for i := start; i < end; i++ {
+				err := d.buildRootFromType(elemType, sourceValue.Index(i), whh, false, idt+2)
+				if err != nil {
+					workerErrs[worker] = wrapIndexError(err, i)
+					return
+				}
+
+				copy(roots[i*32:(i+1)*32], whh.Hash())
+				whh.Reset()
+			}
+		}(w, start, end)
+	}
+	wg.Wait()
+
+	for _, err := range workerErrs {
+		if err != nil {
+			return err
+		}
+	}
+
+	hh.Append(roots)
+
+	return nil
+}
+
 // getActiveFields returns the active fields for a progressive container.
 // Per the specification: Given a value of type ProgressiveContainer(active_fields)
 // return value.__class__.active_fields.
@@ -5,9 +5,14 @@ package dynssz_test
 
 import (
 	"bytes"
+	"fmt"
+	"math/big"
+	"sync"
 	"testing"
 
 	. "github.com/pk910/dynamic-ssz"
+	"github.com/pk910/dynamic-ssz/hasher"
+	"github.com/pk910/dynamic-ssz/sszutils"
 )
 
 var treerootTestMatrix = []struct {
@@ -184,6 +189,12 @@ var treerootTestMatrix = []struct {
 		}{[4]byte{0xff, 0x0f, 0x00, 0xf0}},
 		fromHex("0xff0f00f000000000000000000000000000000000000000000000000000000000"),
 	},
+	{
+		struct {
+			Flags [2]byte `ssz-bitsize:"12"`
+		}{[2]byte{0xff, 0x0f}},
+		fromHex("0xff0f000000000000000000000000000000000000000000000000000000000000"),
+	},
 
 	// explicit basic type annotations
 	{
@@ -294,6 +305,135 @@ var treerootTestMatrix = []struct {
 		fromHex("0x631276fc281634b5224241dd547762be15e2f54e361c6bdc8f921a4d5125e954"),
 	},
 
+	// Union tests (selector 0 is the None option)
+	{
+		struct {
+			A uint16
+			B Union[struct {
+				None  UnionNone
+				Value uint32
+				List  []uint8 `ssz-max:"8"`
+			}]
+			C uint16
+		}{0x1337, Union[struct {
+			None  UnionNone
+			Value uint32
+			List  []uint8 `ssz-max:"8"`
+		}]{Selector: 0}, 0x4242},
+		fromHex("0x67fa1b40d8e9170fe2cab42df22a14602ea7f52087a9765c28fb867576204732"),
+	},
+	{
+		struct {
+			A uint16
+			B Union[struct {
+				None  UnionNone
+				Value uint32
+				List  []uint8 `ssz-max:"8"`
+			}]
+			C uint16
+		}{0x1337, Union[struct {
+			None  UnionNone
+			Value uint32
+			List  []uint8 `ssz-max:"8"`
+		}]{Selector: 1, Data: uint32(0x12345678)}, 0x4242},
+		fromHex("0x545fedc025d1b21b79e65cfec615bb165a559fa5e4ff762e2de5e731e2a242c3"),
+	},
+	{
+		struct {
+			A uint16
+			B Union[struct {
+				None  UnionNone
+				Value uint32
+				List  []uint8 `ssz-max:"8"`
+			}]
+			C uint16
+		}{0x1337, Union[struct {
+			None  UnionNone
+			Value uint32
+			List  []uint8 `ssz-max:"8"`
+		}]{Selector: 2, Data: []uint8{1, 2, 3}}, 0x4242},
+		fromHex("0x148dd354c067f7e6cf37a571bdd10a491f3616e7c93ee3175915d010943f8994"),
+	},
+
+	// Optional tests (EIP-6475)
+	{
+		struct {
+			A uint16
+			B *uint64 `ssz-type:"optional"`
+			C uint16
+		}{0x1337, nil, 0x4242},
+		fromHex("0x67fa1b40d8e9170fe2cab42df22a14602ea7f52087a9765c28fb867576204732"),
+	},
+	{
+		struct {
+			A uint16
+			B *uint64 `ssz-type:"optional"`
+			C uint16
+		}{0x1337, func() *uint64 { v := uint64(0x1122); return &v }(), 0x4242},
+		fromHex("0x09d4f49a539cbbb775d57764fb8b80cdf73d575ea25165b6763c087d7ca15301"),
+	},
+	{
+		struct {
+			A uint16
+			B *[]uint8 `ssz-type:"optional" ssz-max:"8"`
+			C uint16
+		}{0x1337, &[]uint8{1, 2, 3}, 0x4242},
+		fromHex("0xe8b163b8b4b701cc52e9028f9cbc9a8d0481592601eb3a579612c01d9388ed7b"),
+	},
+
+	// StableContainer tests (EIP-7495)
+	{
+		struct {
+			A uint16
+			S struct {
+				X *uint16
+				Y *[]uint8 `ssz-max:"8"`
+				Z *uint32
+			} `ssz-type:"stable-container" ssz-size:"4"`
+			C uint16
+		}{A: 0x1337, C: 0x4242},
+		fromHex("0x93b52fc29598b539f876646ccf4499c1523abc98491c028b5ec3966ff94350d4"),
+	},
+	{
+		struct {
+			A uint16
+			S struct {
+				X *uint16
+				Y *[]uint8 `ssz-max:"8"`
+				Z *uint32
+			} `ssz-type:"stable-container" ssz-size:"4"`
+			C uint16
+		}{A: 0x1337, C: 0x4242, S: struct {
+			X *uint16
+			Y *[]uint8 `ssz-max:"8"`
+			Z *uint32
+		}{X: func() *uint16 { v := uint16(0x1122); return &v }(), Y: &[]uint8{1, 2, 3}}},
+		fromHex("0x33476402dce806149263a077cb6c425465c951b022e00bb814bba3367b0fef8f"),
+	},
+
+	// map types (sorted list of key/value entries)
+	{
+		struct {
+			A uint16
+			M map[uint64]uint16 `ssz-max:"4"`
+			C uint16
+		}{0x1337, map[uint64]uint16{5: 0x3344, 1: 0x1122, 3: 0x5566}, 0x4242},
+		fromHex("0xd95e54a31dce0b7e4ced9435342ef5b029a7f3f3b8ab58d7145d8b36d8339d3a"),
+	},
+	{
+		struct {
+			A uint16
+			M map[uint32][]uint8 `ssz-max:"4,8"`
+		}{0x1337, map[uint32][]uint8{2: {1, 2}, 1: {}}},
+		fromHex("0x9b8eed618a0bd37571b2f2473eabf32d168943b5664308bf1be20b824145884d"),
+	},
+	{
+		struct {
+			M map[uint64]uint16 `ssz-max:"4"`
+		}{nil},
+		fromHex("0x28ba1834a3a7b657460ce79fa3a1d909ab8828fd557659d4d0554a9bdbc0ec30"),
+	},
+
 	// string types
 	{
 		struct {
@@ -394,6 +534,55 @@ func TestTreeRoot(t *testing.T) {
 	}
 }
 
+func TestTreeRootInto(t *testing.T) {
+	dynssz := NewDynSsz(nil)
+	dynssz.NoFastSsz = true
+
+	for idx, test := range treerootTestMatrix {
+		var root [32]byte
+		err := dynssz.HashTreeRootInto(test.payload, &root)
+
+		switch {
+		case test.expected == nil && err != nil:
+			// expected error
+		case err != nil:
+			t.Errorf("test %v error: %v", idx, err)
+		case !bytes.Equal(root[:], test.expected):
+			t.Errorf("test %v failed: got 0x%x, wanted 0x%x", idx, root, test.expected)
+		}
+	}
+
+	// the output is left untouched on errors
+	root := [32]byte{1, 2, 3}
+	err := dynssz.HashTreeRootInto(struct {
+		Flags [1]byte `ssz-type:"bitvector" ssz-bitsize:"4"`
+	}{Flags: [1]byte{0xf0}}, &root)
+	if err == nil || !contains(err.Error(), "Flags: bitvector padding bits are not zero") {
+		t.Errorf("expected bitvector padding error, got %v", err)
+	}
+	if root != [32]byte{1, 2, 3} {
+		t.Errorf("output was modified on error: 0x%x", root)
+	}
+
+	// hashing into the caller's array does not allocate once the type is cached
+	// (not checked with the race detector, as it drops pooled hashers at random)
+	var source any = &struct {
+		Slot  uint64
+		Root  [32]byte
+		Data  []byte   `ssz-max:"64"`
+		Items []uint64 `ssz-max:"16"`
+	}{Slot: 1, Data: []byte{1, 2, 3}, Items: []uint64{1, 2, 3}}
+	if err := dynssz.HashTreeRootInto(source, &root); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = dynssz.HashTreeRootInto(source, &root)
+	})
+	if allocs != 0 && !raceEnabled {
+		t.Errorf("expected no allocations, got %v", allocs)
+	}
+}
+
 func TestStringVsByteContainerTreeRootEquivalence(t *testing.T) {
 	type StringContainer struct {
 		Data string `ssz-max:"100"`
@@ -594,6 +783,13 @@ func TestHashTreeRootErrors(t *testing.T) {
 			}{[]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31, 32, 33}},
 			expectedErr: "large uint type does not have expected data length (33 != 32)",
 		},
+		{
+			name: "bitvector_padding_bits",
+			input: struct {
+				Flags [2]byte `ssz-bitsize:"12"`
+			}{[2]byte{0xff, 0x1f}},
+			expectedErr: "bitvector padding bits are not zero",
+		},
 		{
 			name: "invalid_bitvector_type",
 			input: struct {
@@ -646,7 +842,7 @@ func TestHashTreeRootErrors(t *testing.T) {
 			input: struct {
 				Data map[string]int
 			}{map[string]int{"a": 1}},
-			expectedErr: "maps are not supported in SSZ",
+			expectedErr: "map ssz type can only be represented by maps with unsigned integer keys",
 		},
 		{
 			name: "invalid_interface_type",
@@ -743,3 +939,739 @@ func TestHashTreeRootErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestTreeRootParallel(t *testing.T) {
+	type Element struct {
+		Index   uint64
+		Payload []uint8 `ssz-max:"64"`
+		Flag    bool
+	}
+
+	type ListContainer struct {
+		Elements []*Element `ssz-max:"4096"`
+	}
+
+	type VectorContainer struct {
+		Elements [300]Element
+	}
+
+	makeElements := func(count int) []*Element {
+		elements := make([]*Element, count)
+		for i := range elements {
+			elements[i] = &Element{
+				Index:   uint64(i),
+				Payload: bytes.Repeat([]byte{byte(i)}, i%64),
+				Flag:    i%3 == 0,
+			}
+		}
+		return elements
+	}
+
+	vectorContainer := VectorContainer{}
+	for i, element := range makeElements(300) {
+		vectorContainer.Elements[i] = *element
+	}
+
+	testCases := []struct {
+		name    string
+		payload any
+	}{
+		{"below_threshold", ListContainer{Elements: makeElements(100)}},
+		{"list", ListContainer{Elements: makeElements(1000)}},
+		{"list_with_nil", ListContainer{Elements: append(makeElements(511), nil, &Element{Index: 1})}},
+		{"vector", vectorContainer},
+	}
+
+	serialSsz := NewDynSsz(nil)
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			expected, err := serialSsz.HashTreeRoot(tc.payload)
+			if err != nil {
+				t.Fatalf("serial hashing failed: %v", err)
+			}
+
+			for _, concurrency := range []int{2, 3, 8, 64} {
+				parallelSsz := NewDynSsz(nil)
+				parallelSsz.MaxConcurrency = concurrency
+
+				root, err := parallelSsz.HashTreeRoot(tc.payload)
+				if err != nil {
+					t.Fatalf("parallel hashing (concurrency %d) failed: %v", concurrency, err)
+				}
+
+				if root != expected {
+					t.Errorf("parallel root mismatch (concurrency %d): got 0x%x, wanted 0x%x", concurrency, root, expected)
+				}
+			}
+		})
+	}
+
+	t.Run("element_error", func(t *testing.T) {
+		elements := makeElements(1000)
+		elements[700].Payload = make([]byte, 100)
+
+		parallelSsz := NewDynSsz(nil)
+		parallelSsz.MaxConcurrency = 4
+
+		_, err := parallelSsz.HashTreeRoot(ListContainer{Elements: elements})
+		if err == nil {
+			t.Errorf("expected error for oversized element payload, but got no error")
+		} else if !contains(err.Error(), "list too big") {
+			t.Errorf("expected error containing 'list too big', but got: %v", err)
+		}
+	})
+}
+
+func TestTreeRootHasherReuse(t *testing.T) {
+	type Payload struct {
+		A uint64
+		B []uint16 `ssz-max:"8"`
+		C [2]byte  `ssz-bitsize:"12"`
+	}
+
+	ds := NewDynSsz(nil)
+	payload := Payload{A: 1337, B: []uint16{1, 2, 3}, C: [2]byte{0xff, 0x0f}}
+
+	expected, err := ds.HashTreeRoot(payload)
+	if err != nil {
+		t.Fatalf("hashing failed: %v", err)
+	}
+
+	// failed hashing operations leave partial chunks in the hasher, they must not leak into
+	// the next operation that borrows the same hasher from the pool
+	for i := 0; i < 16; i++ {
+		_, err := ds.HashTreeRoot(Payload{A: 1, B: []uint16{1}, C: [2]byte{0xff, 0xff}})
+		if err == nil {
+			t.Fatalf("expected error for invalid bitvector padding, but got no error")
+		}
+
+		root, err := ds.HashTreeRoot(payload)
+		if err != nil {
+			t.Fatalf("hashing failed: %v", err)
+		}
+		if root != expected {
+			t.Fatalf("root mismatch after failed operation: got 0x%x, wanted 0x%x", root, expected)
+		}
+	}
+
+	var wg sync.WaitGroup
+	workerErrs := make(chan error, 32)
+	for w := 0; w < 32; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < 50; i++ {
+				root, err := ds.HashTreeRoot(payload)
+				if err != nil {
+					workerErrs <- err
+					return
+				}
+				if root != expected {
+					workerErrs <- fmt.Errorf("root mismatch: got 0x%x, wanted 0x%x", root, expected)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(workerErrs)
+
+	for err := range workerErrs {
+		t.Error(err)
+	}
+}
+
+func TestTreeRootMultiDimensionalSpecs(t *testing.T) {
+	type DynamicPayload struct {
+		A [][][]byte    `ssz-max:"4,4,16" dynssz-max:"OUTER_MAX,MIDDLE_MAX,INNER_MAX"`
+		B [2][][]uint16 `ssz-max:"?,4,8" dynssz-max:"?,MIDDLE_MAX,INNER_MAX"`
+	}
+	type StaticPayload struct {
+		A [][][]byte    `ssz-max:"8,2,64"`
+		B [2][][]uint16 `ssz-max:"?,2,64"`
+	}
+
+	a := [][][]byte{{{1, 2}, {3}}, {}, {{4, 5, 6}}}
+	b := [2][][]uint16{{{1}, {2, 3}}, {}}
+
+	ds := NewDynSsz(map[string]any{
+		"OUTER_MAX":  uint64(8),
+		"MIDDLE_MAX": uint64(2),
+		"INNER_MAX":  uint64(64),
+	})
+
+	// the spec values must apply to the matching dimension, so the roots equal the static layout
+	dynamicRoot, err := ds.HashTreeRoot(DynamicPayload{A: a, B: b})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	staticRoot, err := ds.HashTreeRoot(StaticPayload{A: a, B: b})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if dynamicRoot != staticRoot {
+		t.Errorf("root mismatch: got 0x%x, wanted 0x%x", dynamicRoot, staticRoot)
+	}
+
+	defaultRoot, err := NewDynSsz(nil).HashTreeRoot(DynamicPayload{A: a, B: b})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if defaultRoot == staticRoot {
+		t.Errorf("expected the default limits to produce a different root")
+	}
+}
+
+// single field containers merkleize to the root of their field, so nesting them
+// rebuilds the tree of a multi-dimensional type with one size/max hint per level
+type mdBytes4 struct {
+	X []byte `ssz-max:"4"`
+}
+type mdBytes16 struct {
+	X []byte `ssz-max:"16"`
+}
+type mdBytes16List4 struct {
+	X []mdBytes16 `ssz-max:"4"`
+}
+type mdBytes16List8 struct {
+	X []mdBytes16 `ssz-max:"8"`
+}
+type mdUint16List8 struct {
+	X []uint16 `ssz-max:"8"`
+}
+type mdUint16List8Vector2 struct {
+	X []mdUint16List8 `ssz-size:"2"`
+}
+type mdUint16Vector3 struct {
+	X [3]uint16
+}
+type mdUint16Vector3List8 struct {
+	X []mdUint16Vector3 `ssz-max:"8"`
+}
+type mdBytes4Vector2 struct {
+	X [2]mdBytes4
+}
+type mdNestedElement struct {
+	X []mdBytes4 `ssz-max:"2"`
+	Y uint8
+}
+
+// mdWrap wraps every item of a slice.
+func mdWrap[T, W any](items []T, wrap func(T) W) []W {
+	wrapped := make([]W, len(items))
+	for i, item := range items {
+		wrapped[i] = wrap(item)
+	}
+	return wrapped
+}
+
+func mdWrapBytes4(v []byte) mdBytes4   { return mdBytes4{X: v} }
+func mdWrapBytes16(v []byte) mdBytes16 { return mdBytes16{X: v} }
+
+func TestTreeRootMultiDimensionalHints(t *testing.T) {
+	bytes3d := [][][]byte{{{1, 2}, {3}}, {}, {{4, 5, 6}}}
+	uint16s3d := [][][]uint16{{{1}, {2, 3}}, {{}, {4}}}
+	bytesVector := [2][][]byte{{{1}, {2, 3}}, {}}
+	elements := []struct {
+		x [][]byte
+		y uint8
+	}{{[][]byte{{1, 2}, {3}}, 7}, {[][]byte{}, 8}}
+	bytesMatrix := [2][2][]uint8{{{1}, {}}, {{2, 3}, {4}}}
+	vectors := [][][3]uint16{{{1, 2, 3}, {4, 5, 6}}, {}, {{7, 8, 9}}}
+
+	testCases := []struct {
+		name     string
+		payload  any
+		expected any
+	}{
+		{
+			name: "list_list_list",
+			payload: struct {
+				A [][][]byte `ssz-max:"4,8,16"`
+			}{bytes3d},
+			expected: struct {
+				A []mdBytes16List8 `ssz-max:"4"`
+			}{mdWrap(bytes3d, func(v [][]byte) mdBytes16List8 {
+				return mdBytes16List8{X: mdWrap(v, mdWrapBytes16)}
+			})},
+		},
+		{
+			name: "list_vector_list",
+			payload: struct {
+				A [][][]uint16 `ssz-size:"?,2,?" ssz-max:"4,?,8"`
+			}{uint16s3d},
+			expected: struct {
+				A []mdUint16List8Vector2 `ssz-max:"4"`
+			}{mdWrap(uint16s3d, func(v [][]uint16) mdUint16List8Vector2 {
+				return mdUint16List8Vector2{X: mdWrap(v, func(v []uint16) mdUint16List8 { return mdUint16List8{X: v} })}
+			})},
+		},
+		{
+			name: "vector_list_list",
+			payload: struct {
+				A [2][][]byte `ssz-max:"?,4,16"`
+			}{bytesVector},
+			expected: struct {
+				A [2]mdBytes16List4
+			}{[2]mdBytes16List4{
+				{X: mdWrap(bytesVector[0], mdWrapBytes16)},
+				{X: mdWrap(bytesVector[1], mdWrapBytes16)},
+			}},
+		},
+		{
+			name: "list_of_containers",
+			payload: struct {
+				A []struct {
+					X [][]byte `ssz-max:"2,4"`
+					Y uint8
+				} `ssz-max:"3"`
+			}{[]struct {
+				X [][]byte `ssz-max:"2,4"`
+				Y uint8
+			}{{X: elements[0].x, Y: elements[0].y}, {X: elements[1].x, Y: elements[1].y}}},
+			expected: struct {
+				A []mdNestedElement `ssz-max:"3"`
+			}{[]mdNestedElement{
+				{X: mdWrap(elements[0].x, mdWrapBytes4), Y: elements[0].y},
+				{X: mdWrap(elements[1].x, mdWrapBytes4), Y: elements[1].y},
+			}},
+		},
+		{
+			name: "vector_vector_list",
+			payload: struct {
+				A [2][2][]uint8 `ssz-max:"?,?,4"`
+				B uint16
+			}{bytesMatrix, 0x1337},
+			expected: struct {
+				A [2]mdBytes4Vector2
+				B uint16
+			}{[2]mdBytes4Vector2{
+				{X: [2]mdBytes4{{X: bytesMatrix[0][0]}, {X: bytesMatrix[0][1]}}},
+				{X: [2]mdBytes4{{X: bytesMatrix[1][0]}, {X: bytesMatrix[1][1]}}},
+			}, 0x1337},
+		},
+		{
+			name: "list_list_vector",
+			payload: struct {
+				A [][][3]uint16 `ssz-max:"4,8"`
+			}{vectors},
+			expected: struct {
+				A []mdUint16Vector3List8 `ssz-max:"4"`
+			}{mdWrap(vectors, func(v [][3]uint16) mdUint16Vector3List8 {
+				return mdUint16Vector3List8{X: mdWrap(v, func(v [3]uint16) mdUint16Vector3 { return mdUint16Vector3{X: v} })}
+			})},
+		},
+	}
+
+	ds := NewDynSsz(nil)
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			root, err := ds.HashTreeRoot(tc.payload)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			expected, err := ds.HashTreeRoot(tc.expected)
+			if err != nil {
+				t.Fatalf("unexpected error for nested equivalent: %v", err)
+			}
+
+			if root != expected {
+				t.Errorf("root mismatch: got 0x%x, wanted 0x%x", root, expected)
+			}
+		})
+	}
+}
+
+// PrecomputedRoot supplies a precomputed root instead of hashing its fields
+type PrecomputedRoot struct {
+	Data []byte `ssz-max:"64"`
+	Root [32]byte
+}
+
+func (r *PrecomputedRoot) HashTreeRootDyn(ds any, hh sszutils.HashWalker) error {
+	hh.PutBytes(r.Root[:])
+	return nil
+}
+
+// DoubledPair merkleizes its own chunks, starting at the current hasher index
+type DoubledPair struct {
+	A uint64
+	B uint64
+}
+
+func (p DoubledPair) HashTreeRootDyn(ds any, hh sszutils.HashWalker) error {
+	hashIndex := hh.Index()
+	hh.PutUint64(p.A * 2)
+	hh.PutUint64(p.B * 2)
+	hh.Merkleize(hashIndex)
+	return nil
+}
+
+// UnmerkleizedPair leaves two chunks in the hasher, which breaks the parent layout
+type UnmerkleizedPair struct {
+	A uint64
+	B uint64
+}
+
+func (p *UnmerkleizedPair) HashTreeRootDyn(ds any, hh sszutils.HashWalker) error {
+	hh.PutUint64(p.A)
+	hh.PutUint64(p.B)
+	return nil
+}
+
+func TestTreeRootDynamicHashRoot(t *testing.T) {
+	type Payload struct {
+		A  uint64
+		R  PrecomputedRoot
+		L  []PrecomputedRoot `ssz-max:"4"`
+		P  DoubledPair
+		PL []*DoubledPair `ssz-max:"4"`
+	}
+
+	payload := Payload{
+		A:  1,
+		R:  PrecomputedRoot{Data: []byte{0xff}, Root: [32]byte{1, 2, 3}},
+		L:  []PrecomputedRoot{{Root: [32]byte{4}}, {Root: [32]byte{5}}},
+		P:  DoubledPair{A: 3, B: 4},
+		PL: []*DoubledPair{{A: 5, B: 6}},
+	}
+
+	// the equivalent plain payload holds the values the hooks put into the hasher
+	type PlainPair struct {
+		A uint64
+		B uint64
+	}
+	type PlainPayload struct {
+		A  uint64
+		R  [32]byte
+		L  [][32]byte `ssz-max:"4"`
+		P  PlainPair
+		PL []*PlainPair `ssz-max:"4"`
+	}
+
+	ds := NewDynSsz(nil)
+
+	expected, err := ds.HashTreeRoot(PlainPayload{
+		A:  1,
+		R:  [32]byte{1, 2, 3},
+		L:  [][32]byte{{4}, {5}},
+		P:  PlainPair{A: 6, B: 8},
+		PL: []*PlainPair{{A: 10, B: 12}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// hash by value and by pointer, the hooks of non-addressable values are called on a copy
+	for _, source := range []any{payload, &payload} {
+		root, err := ds.HashTreeRoot(source)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if root != expected {
+			t.Errorf("root mismatch: got 0x%x, wanted 0x%x", root, expected)
+		}
+	}
+
+	_, err = ds.HashTreeRoot(struct {
+		A UnmerkleizedPair
+	}{})
+	expectedErr := "A: HashTreeRootDyn of dynssz_test.UnmerkleizedPair must produce exactly one 32 byte root, got 64 bytes"
+	if err == nil || err.Error() != expectedErr {
+		t.Errorf("expected error '%v', got %v", expectedErr, err)
+	}
+}
+
+type embeddedDynamicField struct {
+	Values []uint64 `ssz-max:"8" dynssz-max:"MAX_VALUES"`
+}
+
+// FastsszParent mimics a fastssz generated type that delegates the hashing of a field with dynamic layout
+type FastsszParent struct {
+	ds    *DynSsz
+	Slot  uint64
+	Inner embeddedDynamicField
+}
+
+func (p *FastsszParent) HashTreeRootWith(hh sszutils.HashWalker) error {
+	indx := hh.Index()
+	hh.PutUint64(p.Slot)
+	if err := p.ds.HashTreeRootWith(&p.Inner, hh); err != nil {
+		return err
+	}
+	hh.PutUint64(42)
+	hh.Merkleize(indx)
+	return nil
+}
+
+// wrappedHasher is a foreign HashWalker implementation
+type wrappedHasher struct {
+	*hasher.Hasher
+}
+
+func TestHashTreeRootWith(t *testing.T) {
+	ds := NewDynSsz(map[string]any{"MAX_VALUES": uint64(64)})
+
+	expected, err := ds.HashTreeRoot(struct {
+		Slot  uint64
+		Inner embeddedDynamicField
+		Tail  uint64
+	}{7, embeddedDynamicField{Values: []uint64{1, 2, 3}}, 42})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	parent := &FastsszParent{ds: ds, Slot: 7, Inner: embeddedDynamicField{Values: []uint64{1, 2, 3}}}
+
+	for _, hh := range []sszutils.HashWalker{hasher.NewHasher(), wrappedHasher{hasher.NewHasher()}} {
+		if err := parent.HashTreeRootWith(hh); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !bytes.Equal(hh.Hash(), expected[:]) {
+			t.Errorf("root mismatch (%T): got 0x%x, wanted 0x%x", hh, hh.Hash(), expected)
+		}
+	}
+
+	err = ds.HashTreeRootWith(&embeddedDynamicField{Values: make([]uint64, 65)}, hasher.NewHasher())
+	if err == nil || !contains(err.Error(), "embeddedDynamicField.Values: list too big") {
+		t.Errorf("expected list limit error, got %v", err)
+	}
+}
+
+// CommitteeBits is a bitlist type detected via the sszutils.BitlistMarker interface
+type CommitteeBits []byte
+
+func (CommitteeBits) SszBitlist() {}
+
+// LegacyBitlist is a bitlist type detected by the deprecated name based detection
+type LegacyBitlist []byte
+
+// BitlistCounts is a regular list, the name based detection only applies to byte slices
+type BitlistCounts []uint64
+
+func TestBitlistTypeDetection(t *testing.T) {
+	expectedRoot := func(t *testing.T, payload any) [32]byte {
+		root, err := NewDynSsz(nil).HashTreeRoot(payload)
+		if err != nil {
+			t.Fatalf("failed to hash reference payload: %v", err)
+		}
+		return root
+	}
+
+	bits := []byte{0xf3, 0x0a, 0x01}
+
+	testCases := []struct {
+		name      string
+		payload   any
+		reference any
+	}{
+		{
+			name: "marker_interface",
+			payload: struct {
+				Bits CommitteeBits `ssz-max:"100"`
+			}{bits},
+			reference: struct {
+				Bits []byte `ssz-type:"bitlist" ssz-max:"100"`
+			}{bits},
+		},
+		{
+			name: "legacy_name",
+			payload: struct {
+				Bits LegacyBitlist `ssz-max:"100"`
+			}{bits},
+			reference: struct {
+				Bits []byte `ssz-type:"bitlist" ssz-max:"100"`
+			}{bits},
+		},
+		{
+			name: "tag_overrides_marker",
+			payload: struct {
+				Bits CommitteeBits `ssz-type:"list" ssz-max:"100"`
+			}{bits},
+			reference: struct {
+				Bits []byte `ssz-max:"100"`
+			}{bits},
+		},
+		{
+			name: "non_byte_slice",
+			payload: struct {
+				Counts BitlistCounts `ssz-max:"100"`
+			}{BitlistCounts{1, 2, 3}},
+			reference: struct {
+				Counts []uint64 `ssz-max:"100"`
+			}{[]uint64{1, 2, 3}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			root, err := NewDynSsz(nil).HashTreeRoot(tc.payload)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			expected := expectedRoot(t, tc.reference)
+			if root != expected {
+				t.Errorf("root mismatch: got 0x%x, wanted 0x%x", root, expected)
+			}
+		})
+	}
+}
+
+// Uint256Marked is a 256 bit integer type detected via the sszutils.LargeUintMarker interface
+type Uint256Marked [4]uint64
+
+func (Uint256Marked) SszUintBits() int { return 256 }
+
+// Uint128Marked is a 128 bit integer type using a pointer receiver for the marker method
+type Uint128Marked [16]byte
+
+func (*Uint128Marked) SszUintBits() int { return 128 }
+
+// UintInvalidMarked reports an unsupported bit width
+type UintInvalidMarked [4]uint64
+
+func (UintInvalidMarked) SszUintBits() int { return 64 }
+
+func TestLargeUintTypeDetection(t *testing.T) {
+	u128 := Uint128Marked{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+
+	payload := struct {
+		A Uint256Marked
+		B *Uint128Marked
+		C []Uint256Marked `ssz-max:"4"`
+	}{Uint256Marked{1, 2, 3, 4}, &u128, []Uint256Marked{{5}, {6}}}
+
+	reference := struct {
+		A [4]uint64   `ssz-type:"uint256"`
+		B [16]byte    `ssz-type:"uint128"`
+		C [][4]uint64 `ssz-type:"list,uint256" ssz-max:"4"`
+	}{[4]uint64{1, 2, 3, 4}, [16]byte(u128), [][4]uint64{{5}, {6}}}
+
+	ds := NewDynSsz(nil)
+
+	root, err := ds.HashTreeRoot(payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedRoot, err := ds.HashTreeRoot(reference)
+	if err != nil {
+		t.Fatalf("failed to hash reference payload: %v", err)
+	}
+	if root != expectedRoot {
+		t.Errorf("root mismatch: got 0x%x, wanted 0x%x", root, expectedRoot)
+	}
+
+	data, err := ds.MarshalSSZ(payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedData, err := ds.MarshalSSZ(reference)
+	if err != nil {
+		t.Fatalf("failed to marshal reference payload: %v", err)
+	}
+	if !bytes.Equal(data, expectedData) {
+		t.Errorf("marshal mismatch: got 0x%x, wanted 0x%x", data, expectedData)
+	}
+
+	_, err = ds.HashTreeRoot(struct{ A UintInvalidMarked }{})
+	if err == nil {
+		t.Errorf("expected error for invalid bit width, but got no error")
+	} else if !contains(err.Error(), "unsupported bit width") {
+		t.Errorf("expected error containing 'unsupported bit width', but got: %v", err)
+	}
+
+	// arbitrary precision integers are rejected explicitly, even with a uint256 type tag
+	bigIntPayloads := []any{
+		struct{ A *big.Int }{big.NewInt(5)},
+		struct{ A big.Int }{},
+		struct {
+			A *big.Int `ssz-type:"uint256"`
+		}{big.NewInt(5)},
+	}
+	for i, bigIntPayload := range bigIntPayloads {
+		_, err = ds.HashTreeRoot(bigIntPayload)
+		if err == nil || !contains(err.Error(), "big.Int is not supported in SSZ") {
+			t.Errorf("big.Int payload %v: expected unsupported type error, got %v", i, err)
+		}
+	}
+}
+
+// StableShape is a stable container type detected via the sszutils.StableContainerMarker interface
+type StableShape struct {
+	Side  *uint16
+	Color *uint8
+	Radii *[]uint8 `ssz-max:"8"`
+}
+
+func (StableShape) SszStableCapacity() int { return 4 }
+
+// StableInvalidCapacity reports an invalid capacity
+type StableInvalidCapacity struct {
+	A *uint16
+}
+
+func (StableInvalidCapacity) SszStableCapacity() int { return 0 }
+
+func TestStableContainerTypeDetection(t *testing.T) {
+	side := uint16(0x1122)
+	payload := &StableShape{
+		Side:  &side,
+		Radii: &[]uint8{1, 2, 3},
+	}
+
+	// the marked type hashes and encodes like the tagged field of a single field container
+	reference := struct {
+		Shape *StableShape `ssz-type:"stable-container" ssz-size:"4"`
+	}{payload}
+
+	ds := NewDynSsz(nil)
+
+	root, err := ds.HashTreeRoot(payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedRoot, err := ds.HashTreeRoot(reference)
+	if err != nil {
+		t.Fatalf("failed to hash reference payload: %v", err)
+	}
+	if root != expectedRoot {
+		t.Errorf("root mismatch: got 0x%x, wanted 0x%x", root, expectedRoot)
+	}
+
+	data, err := ds.MarshalSSZ(payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedData, err := ds.MarshalSSZ(reference)
+	if err != nil {
+		t.Fatalf("failed to marshal reference payload: %v", err)
+	}
+	if !bytes.Equal(data, expectedData[4:]) {
+		t.Errorf("marshal mismatch: got 0x%x, wanted 0x%x", data, expectedData[4:])
+	}
+
+	decoded := &StableShape{}
+	if err := ds.UnmarshalSSZ(decoded, data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if decoded.Side == nil || *decoded.Side != side || decoded.Color != nil || decoded.Radii == nil || !bytes.Equal(*decoded.Radii, []uint8{1, 2, 3}) {
+		t.Errorf("unexpected decoded value: %+v", decoded)
+	}
+
+	_, err = ds.HashTreeRoot(struct {
+		Shape StableShape `ssz-type:"stable-container" ssz-size:"5"`
+	}{})
+	if err == nil || !contains(err.Error(), "stable container capacity of type dynssz_test.StableShape does not match ssz-size (4 != 5)") {
+		t.Errorf("expected capacity mismatch error, got %v", err)
+	}
+
+	_, err = ds.HashTreeRoot(StableInvalidCapacity{})
+	if err == nil || !contains(err.Error(), "invalid stable container capacity") {
+		t.Errorf("expected invalid capacity error, got %v", err)
+	}
+}
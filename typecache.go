@@ -5,16 +5,28 @@ package dynssz
 
 import (
 	"fmt"
+	"math/big"
 	"reflect"
 	"strings"
 	"sync"
 )
 
+var bigIntType = reflect.TypeOf((*big.Int)(nil)).Elem()
+
 // TypeCache manages cached type descriptors
 type TypeCache struct {
-	dynssz      *DynSsz
-	mutex       sync.RWMutex
-	descriptors map[reflect.Type]*TypeDescriptor
+	dynssz            *DynSsz
+	mutex             sync.RWMutex
+	descriptors       map[reflect.Type]*TypeDescriptor
+	hintedDescriptors map[hintedTypeKey]*TypeDescriptor
+	buildStack        []reflect.Type // types currently being built, used to detect recursive types
+}
+
+// hintedTypeKey identifies a type descriptor that was built with size, max size or type hints.
+// The hints already carry the resolved spec values, so the key also covers the dynamic layout.
+type hintedTypeKey struct {
+	Type  reflect.Type
+	Hints string
 }
 
 // SszTypeFlag is a flag indicating whether a type has a specific SSZ type feature
@@ -56,9 +68,10 @@ type TypeDescriptor struct {
 	Kind                   reflect.Kind              // Go kind of the type
 	Size                   uint32                    // SSZ size (-1 if dynamic)
 	Len                    uint32                    // Length of array/slice
+	BitSize                uint32                    // Length in bits for bitvectors (ssz-bitsize tag)
 	Limit                  uint64                    // Limit of array/slice (ssz-max tag)
 	ContainerDesc          *ContainerDescriptor      // For structs
-	UnionVariants          map[uint8]*TypeDescriptor // Union variant types by index (for CompatibleUnion)
+	UnionVariants          map[uint8]*TypeDescriptor // Union variant types by index (for CompatibleUnion and Union, nil for the None option)
 	ElemDesc               *TypeDescriptor           // For slices/arrays
 	HashTreeRootWithMethod *reflect.Method           // Cached HashTreeRootWith method for performance
 	SizeExpression         *string                   // The dynamic expression used to calculate the size of the type
@@ -92,8 +105,28 @@ type DynFieldDescriptor struct {
 // NewTypeCache creates a new type cache
 func NewTypeCache(dynssz *DynSsz) *TypeCache {
 	return &TypeCache{
-		dynssz:      dynssz,
-		descriptors: make(map[reflect.Type]*TypeDescriptor),
+		dynssz:            dynssz,
+		descriptors:       make(map[reflect.Type]*TypeDescriptor),
+		hintedDescriptors: make(map[hintedTypeKey]*TypeDescriptor),
+	}
+}
+
+// getHintedTypeKey returns the cache key for a type that is used with the given hints.
+func getHintedTypeKey(t reflect.Type, sizeHints []SszSizeHint, maxSizeHints []SszMaxSizeHint, typeHints []SszTypeHint) hintedTypeKey {
+	var hints strings.Builder
+	for _, hint := range sizeHints {
+		fmt.Fprintf(&hints, "s%d,%t,%t,%d,%q;", hint.Size, hint.Dynamic, hint.Custom, hint.Bits, hint.Expr)
+	}
+	for _, hint := range maxSizeHints {
+		fmt.Fprintf(&hints, "m%d,%t,%t,%q;", hint.Size, hint.NoValue, hint.Custom, hint.Expr)
+	}
+	for _, hint := range typeHints {
+		fmt.Fprintf(&hints, "t%d;", hint.Type)
+	}
+
+	return hintedTypeKey{
+		Type:  t,
+		Hints: hints.String(),
 	}
 }
 
@@ -117,9 +150,9 @@ func NewTypeCache(dynssz *DynSsz) *TypeCache {
 //   - *TypeDescriptor: The type descriptor containing metadata for SSZ operations
 //   - error: An error if the type cannot be analyzed or contains unsupported features
 //
-// Type descriptors are only cached when no size hints are provided (i.e., for root types).
-// When size hints are present, the descriptor is computed dynamically to accommodate the
-// specific constraints.
+// Type descriptors are cached per type and combination of hints. As the hints carry the
+// resolved specification values of this DynSsz instance, a cached descriptor always reflects
+// the layout for the spec values in effect.
 //
 // Example:
 //
@@ -129,15 +162,17 @@ func NewTypeCache(dynssz *DynSsz) *TypeCache {
 //	}
 //	fmt.Printf("Type size: %d bytes (dynamic: %v)\n", typeDesc.Size, typeDesc.Size < 0)
 func (tc *TypeCache) GetTypeDescriptor(t reflect.Type, sizeHints []SszSizeHint, maxSizeHints []SszMaxSizeHint, typeHints []SszTypeHint) (*TypeDescriptor, error) {
+	if t == nil {
+		return nil, fmt.Errorf("cannot determine type of nil value")
+	}
+
 	// Check cache first (read lock)
-	if len(sizeHints) == 0 && len(maxSizeHints) == 0 && len(typeHints) == 0 {
-		tc.mutex.RLock()
-		if desc, exists := tc.descriptors[t]; exists {
-			tc.mutex.RUnlock()
-			return desc, nil
-		}
+	tc.mutex.RLock()
+	if desc := tc.getCachedDescriptor(t, sizeHints, maxSizeHints, typeHints); desc != nil {
 		tc.mutex.RUnlock()
+		return desc, nil
 	}
+	tc.mutex.RUnlock()
 
 	// If not in cache, build and cache it (write lock)
 	tc.mutex.Lock()
@@ -146,10 +181,19 @@ func (tc *TypeCache) GetTypeDescriptor(t reflect.Type, sizeHints []SszSizeHint,
 	return tc.getTypeDescriptor(t, sizeHints, maxSizeHints, typeHints)
 }
 
+// getCachedDescriptor returns the cached type descriptor for the type and hints, or nil if not cached.
+// The caller must hold the mutex.
+func (tc *TypeCache) getCachedDescriptor(t reflect.Type, sizeHints []SszSizeHint, maxSizeHints []SszMaxSizeHint, typeHints []SszTypeHint) *TypeDescriptor {
+	if len(sizeHints) == 0 && len(maxSizeHints) == 0 && len(typeHints) == 0 {
+		return tc.descriptors[t]
+	}
+
+	return tc.hintedDescriptors[getHintedTypeKey(t, sizeHints, maxSizeHints, typeHints)]
+}
+
 // getTypeDescriptor returns a cached type descriptor, computing it if necessary
 func (tc *TypeCache) getTypeDescriptor(t reflect.Type, sizeHints []SszSizeHint, maxSizeHints []SszMaxSizeHint, typeHints []SszTypeHint) (*TypeDescriptor, error) {
-	cacheable := len(sizeHints) == 0 && len(maxSizeHints) == 0 && len(typeHints) == 0
-	if desc, exists := tc.descriptors[t]; exists && cacheable {
+	if desc := tc.getCachedDescriptor(t, sizeHints, maxSizeHints, typeHints); desc != nil {
 		return desc, nil
 	}
 
@@ -158,26 +202,74 @@ func (tc *TypeCache) getTypeDescriptor(t reflect.Type, sizeHints []SszSizeHint,
 		return nil, err
 	}
 
-	// Cache only if no size hints (cacheable)
-	if cacheable {
+	if len(sizeHints) == 0 && len(maxSizeHints) == 0 && len(typeHints) == 0 {
 		tc.descriptors[t] = desc
+	} else {
+		tc.hintedDescriptors[getHintedTypeKey(t, sizeHints, maxSizeHints, typeHints)] = desc
 	}
 
 	return desc, nil
 }
 
+// checkRecursion returns an error if the type is already being built further up the type walk.
+// The error shows the chain of named types from the first occurrence of the type to the repeated one.
+// The caller must hold the mutex.
+func (tc *TypeCache) checkRecursion(t reflect.Type) error {
+	for i, stackType := range tc.buildStack {
+		if stackType != t {
+			continue
+		}
+
+		chain := make([]string, 0, len(tc.buildStack)-i+1)
+		for _, chainType := range tc.buildStack[i:] {
+			if chainType.Name() != "" {
+				chain = append(chain, chainType.Name())
+			}
+		}
+		chain = append(chain, t.Name())
+
+		return fmt.Errorf("recursive type detected: %v", strings.Join(chain, " -> "))
+	}
+
+	return nil
+}
+
 // buildTypeDescriptor computes a type descriptor for the given type
 func (tc *TypeCache) buildTypeDescriptor(t reflect.Type, sizeHints []SszSizeHint, maxSizeHints []SszMaxSizeHint, typeHints []SszTypeHint) (*TypeDescriptor, error) {
 	desc := &TypeDescriptor{
 		Type: t,
 	}
 
+	// Optional types are represented by pointers, the pointer itself carries the presence information
+	if len(typeHints) > 0 && typeHints[0].Type == SszOptionalType {
+		err := tc.buildOptionalDescriptor(desc, t, sizeHints, maxSizeHints, typeHints)
+		if err != nil {
+			return nil, err
+		}
+
+		return desc, nil
+	}
+
 	// Handle pointer types
 	if t.Kind() == reflect.Ptr {
 		desc.GoTypeFlags |= GoTypeFlagIsPointer
 		t = t.Elem()
 	}
 
+	// arbitrary precision integers have no fixed width in SSZ
+	if t == bigIntType {
+		return nil, fmt.Errorf("big.Int is not supported in SSZ (use a fixed-width type like uint256.Int or [32]byte with ssz-type:\"uint256\")")
+	}
+
+	// SSZ types are acyclic, so a type that is nested in itself can never be described
+	if err := tc.checkRecursion(t); err != nil {
+		return nil, err
+	}
+	tc.buildStack = append(tc.buildStack, t)
+	defer func() {
+		tc.buildStack = tc.buildStack[:len(tc.buildStack)-1]
+	}()
+
 	desc.Kind = t.Kind()
 
 	// check dynamic size and max size
@@ -224,16 +316,41 @@ func (tc *TypeCache) buildTypeDescriptor(t reflect.Type, sizeHints []SszSizeHint
 	// auto-detect ssz type if not specified
 	if sszType == SszUnspecifiedType {
 		// detect some well-known and widely used types
+		largeUintType, err := tc.dynssz.getLargeUintCompatibility(t)
+		if err != nil {
+			return nil, err
+		}
+
+		var stableCapacity uint32
+		if desc.Kind == reflect.Struct {
+			stableCapacity, err = tc.dynssz.getStableContainerCapacity(t)
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		switch {
-		case t.PkgPath() == "github.com/holiman/uint256" && t.Name() == "Int":
-			sszType = SszUint256Type
+		case largeUintType != SszUnspecifiedType:
+			sszType = largeUintType
 		case t.PkgPath() == "github.com/pk910/dynamic-ssz" && strings.HasPrefix(t.Name(), "CompatibleUnion["):
 			sszType = SszCompatibleUnionType
+		case t.PkgPath() == "github.com/pk910/dynamic-ssz" && strings.HasPrefix(t.Name(), "Union["):
+			sszType = SszUnionType
+		case t.PkgPath() == "github.com/prysmaticlabs/go-bitfield" && t.Name() == "Bitlist":
+			sszType = SszBitlistType
+		case desc.Kind == reflect.Slice && tc.dynssz.getBitlistMarkerCompatibility(t):
+			sszType = SszBitlistType
+		case desc.Kind == reflect.Struct && stableCapacity > 0:
+			sszType = SszStableContainerType
 		}
 		if t.PkgPath() == typeWrapperType.PkgPath() && strings.HasPrefix(t.Name(), "TypeWrapper[") {
 			sszType = SszTypeWrapperType
 		}
 	}
+	if sszType == SszUnspecifiedType && len(sizeHints) > 0 && sizeHints[0].Bits > 0 {
+		// bit sizes are only meaningful for bitvectors
+		sszType = SszBitvectorType
+	}
 	if sszType == SszUnspecifiedType {
 		switch desc.Kind {
 		// basic types
@@ -265,6 +382,8 @@ func (tc *TypeCache) buildTypeDescriptor(t reflect.Type, sizeHints []SszSizeHint
 			} else {
 				sszType = SszListType
 			}
+		case reflect.Map:
+			sszType = SszMapType
 
 		// unsupported types
 		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
@@ -273,8 +392,6 @@ func (tc *TypeCache) buildTypeDescriptor(t reflect.Type, sizeHints []SszSizeHint
 			return nil, fmt.Errorf("floating-point numbers are not supported in SSZ")
 		case reflect.Complex64, reflect.Complex128:
 			return nil, fmt.Errorf("complex numbers are not supported in SSZ")
-		case reflect.Map:
-			return nil, fmt.Errorf("maps are not supported in SSZ (use structs or arrays instead)")
 		case reflect.Chan:
 			return nil, fmt.Errorf("channels are not supported in SSZ")
 		case reflect.Func:
@@ -287,8 +404,12 @@ func (tc *TypeCache) buildTypeDescriptor(t reflect.Type, sizeHints []SszSizeHint
 			return nil, fmt.Errorf("unsupported type kind: %v", t.Kind())
 		}
 
-		// special case for bitlists
-		if sszType == SszListType && strings.Contains(t.Name(), "Bitlist") {
+		// Deprecated: legacy name based bitlist detection for byte slices.
+		// Use the ssz-type:"bitlist" tag or implement sszutils.BitlistMarker instead.
+		if sszType == SszListType && desc.Kind == reflect.Slice && t.Elem().Kind() == reflect.Uint8 && strings.Contains(t.Name(), "Bitlist") {
+			if tc.dynssz.logEnabled() {
+				tc.dynssz.log(0, "deprecated: type detected as bitlist by name, use ssz-type:\"bitlist\" or sszutils.BitlistMarker instead", "type", t.String())
+			}
 			sszType = SszBitlistType
 		}
 	}
@@ -370,11 +491,26 @@ func (tc *TypeCache) buildTypeDescriptor(t reflect.Type, sizeHints []SszSizeHint
 		if err != nil {
 			return nil, err
 		}
+	case SszStableContainerType:
+		err := tc.buildStableContainerDescriptor(desc, t, sizeHints)
+		if err != nil {
+			return nil, err
+		}
+	case SszMapType:
+		err := tc.buildMapDescriptor(desc, t, sizeHints, maxSizeHints, typeHints)
+		if err != nil {
+			return nil, err
+		}
 	case SszCompatibleUnionType:
 		err := tc.buildCompatibleUnionDescriptor(desc, t)
 		if err != nil {
 			return nil, err
 		}
+	case SszUnionType:
+		err := tc.buildUnionDescriptor(desc, t)
+		if err != nil {
+			return nil, err
+		}
 	case SszCustomType:
 		if len(sizeHints) > 0 && sizeHints[0].Size > 0 {
 			desc.Size = uint32(sizeHints[0].Size)
@@ -654,6 +790,52 @@ func (tc *TypeCache) buildContainerDescriptor(desc *TypeDescriptor, t reflect.Ty
 	return nil
 }
 
+// buildStableContainerDescriptor builds a descriptor for ssz stable containers (EIP-7495)
+//
+// Stable containers have a fixed capacity (ssz-size tag) and only consist of optional fields,
+// which are represented by pointer types. The capacity is stored in desc.Len.
+func (tc *TypeCache) buildStableContainerDescriptor(desc *TypeDescriptor, t reflect.Type, sizeHints []SszSizeHint) error {
+	capacity, err := tc.dynssz.getStableContainerCapacity(t)
+	if err != nil {
+		return err
+	}
+	if len(sizeHints) > 0 && sizeHints[0].Size > 0 {
+		if capacity > 0 && capacity != sizeHints[0].Size {
+			return fmt.Errorf("stable container capacity of type %v does not match ssz-size (%d != %d)", t, capacity, sizeHints[0].Size)
+		}
+		capacity = sizeHints[0].Size
+	}
+	if capacity == 0 {
+		return fmt.Errorf("stable container ssz type requires a capacity (ssz-size tag or sszutils.StableContainerMarker)")
+	}
+
+	err = tc.buildContainerDescriptor(desc, t)
+	if err != nil {
+		return err
+	}
+
+	if desc.SszType != SszStableContainerType {
+		return fmt.Errorf("stable container fields must not have ssz-index tags")
+	}
+
+	desc.Len = capacity
+	if uint32(len(desc.ContainerDesc.Fields)) > desc.Len {
+		return fmt.Errorf("stable container has more fields than its capacity (%d > %d)", len(desc.ContainerDesc.Fields), desc.Len)
+	}
+
+	for _, field := range desc.ContainerDesc.Fields {
+		if field.Type.GoTypeFlags&GoTypeFlagIsPointer == 0 {
+			return fmt.Errorf("stable container field %v must be a pointer type", field.Name)
+		}
+	}
+
+	// stable containers are always dynamic, as only the active fields are serialized
+	desc.Size = 0
+	desc.SszTypeFlags |= SszTypeFlagIsDynamic
+
+	return nil
+}
+
 // buildCompatibleUnionDescriptor builds a descriptor for CompatibleUnion types
 func (tc *TypeCache) buildCompatibleUnionDescriptor(desc *TypeDescriptor, t reflect.Type) error {
 	// CompatibleUnion is always dynamic size (1 byte for type + variable data)
@@ -688,6 +870,53 @@ func (tc *TypeCache) buildCompatibleUnionDescriptor(desc *TypeDescriptor, t refl
 	return nil
 }
 
+// buildUnionDescriptor builds a descriptor for Union types
+func (tc *TypeCache) buildUnionDescriptor(desc *TypeDescriptor, t reflect.Type) error {
+	// Union is always dynamic size (1 byte for the selector + variable data)
+	desc.Size = 0
+	desc.SszTypeFlags |= SszTypeFlagIsDynamic
+
+	descriptorType, err := tc.extractGenericTypeParameter(t)
+	if err != nil {
+		return err
+	}
+
+	variantInfo, err := ExtractUnionDescriptorInfo(descriptorType, tc.dynssz)
+	if err != nil {
+		return fmt.Errorf("failed to extract union variant info: %w", err)
+	}
+
+	if len(variantInfo) > 128 {
+		return fmt.Errorf("union type can have at most 128 options, got %d", len(variantInfo))
+	}
+
+	desc.UnionVariants = make(map[uint8]*TypeDescriptor)
+
+	for variantIndex, info := range variantInfo {
+		if info.Type == unionNoneType {
+			// the None option is represented by a nil descriptor
+			if variantIndex != 0 {
+				return fmt.Errorf("union None option is only allowed as the first option, got selector %d", variantIndex)
+			}
+			if len(variantInfo) == 1 {
+				return fmt.Errorf("union type requires at least one non-None option")
+			}
+
+			desc.UnionVariants[variantIndex] = nil
+			continue
+		}
+
+		variantDesc, err := tc.getTypeDescriptor(info.Type, info.SizeHints, info.MaxSizeHints, info.TypeHints)
+		if err != nil {
+			return fmt.Errorf("failed to build descriptor for union variant %d: %w", variantIndex, err)
+		}
+
+		desc.UnionVariants[variantIndex] = variantDesc
+	}
+
+	return nil
+}
+
 // buildVectorDescriptor builds a descriptor for ssz vector types
 func (tc *TypeCache) buildVectorDescriptor(desc *TypeDescriptor, t reflect.Type, sizeHints []SszSizeHint, maxSizeHints []SszMaxSizeHint, typeHints []SszTypeHint) error {
 	if desc.Kind != reflect.Array && desc.Kind != reflect.Slice && desc.Kind != reflect.String {
@@ -696,7 +925,7 @@ func (tc *TypeCache) buildVectorDescriptor(desc *TypeDescriptor, t reflect.Type,
 
 	if desc.Kind == reflect.Array {
 		desc.Len = uint32(t.Len())
-		if len(sizeHints) > 0 {
+		if len(sizeHints) > 0 && !sizeHints[0].Dynamic {
 			if sizeHints[0].Size > desc.Len {
 				return fmt.Errorf("size hint for vector type is greater than the length of the array (%d > %d)", sizeHints[0].Size, desc.Len)
 			}
@@ -708,6 +937,15 @@ func (tc *TypeCache) buildVectorDescriptor(desc *TypeDescriptor, t reflect.Type,
 		return fmt.Errorf("missing size hint for vector type")
 	}
 
+	if len(sizeHints) > 0 && sizeHints[0].Bits > 0 {
+		if desc.SszType != SszBitvectorType {
+			return fmt.Errorf("bit size hint is only supported for bitvector types")
+		}
+		desc.BitSize = sizeHints[0].Bits
+	} else if desc.SszType == SszBitvectorType {
+		desc.BitSize = desc.Len * 8
+	}
+
 	childSizeHints := []SszSizeHint{}
 	if len(sizeHints) > 1 {
 		childSizeHints = sizeHints[1:]
@@ -756,6 +994,31 @@ func (tc *TypeCache) buildVectorDescriptor(desc *TypeDescriptor, t reflect.Type,
 	return nil
 }
 
+// buildOptionalDescriptor builds a descriptor for ssz optional types (EIP-6475)
+//
+// Optionals are represented by pointer types, a nil pointer represents an absent value.
+// The size and max size hints apply to the wrapped value, so they're passed through unchanged.
+func (tc *TypeCache) buildOptionalDescriptor(desc *TypeDescriptor, t reflect.Type, sizeHints []SszSizeHint, maxSizeHints []SszMaxSizeHint, typeHints []SszTypeHint) error {
+	if t.Kind() != reflect.Ptr {
+		return fmt.Errorf("optional ssz type can only be represented by pointer types, got %v", t.Kind())
+	}
+
+	desc.Kind = t.Kind()
+	desc.SszType = SszOptionalType
+
+	elemDesc, err := tc.getTypeDescriptor(t.Elem(), sizeHints, maxSizeHints, typeHints[1:])
+	if err != nil {
+		return err
+	}
+
+	desc.ElemDesc = elemDesc
+	desc.Size = 0 // optionals are always dynamic
+	desc.SszTypeFlags |= SszTypeFlagIsDynamic
+	desc.SszTypeFlags |= elemDesc.SszTypeFlags & (SszTypeFlagHasDynamicSize | SszTypeFlagHasDynamicMax | SszTypeFlagHasSizeExpr | SszTypeFlagHasMaxExpr)
+
+	return nil
+}
+
 // buildListDescriptor builds a descriptor for ssz list types
 func (tc *TypeCache) buildListDescriptor(desc *TypeDescriptor, t reflect.Type, sizeHints []SszSizeHint, maxSizeHints []SszMaxSizeHint, typeHints []SszTypeHint) error {
 	if desc.Kind != reflect.Slice && desc.Kind != reflect.String {
@@ -815,11 +1078,109 @@ func (tc *TypeCache) buildListDescriptor(desc *TypeDescriptor, t reflect.Type, s
 	return nil
 }
 
+// buildMapDescriptor builds a descriptor for map types
+//
+// SSZ has no map type, so maps are represented as a list of {Key, Value} entry containers, sorted by key
+// in ascending order. The max size (ssz-max tag) limits the number of entries, all other hints apply to
+// the value type. desc.ElemDesc describes the entry list, which is used for all ssz operations.
+func (tc *TypeCache) buildMapDescriptor(desc *TypeDescriptor, t reflect.Type, sizeHints []SszSizeHint, maxSizeHints []SszMaxSizeHint, typeHints []SszTypeHint) error {
+	if desc.Kind != reflect.Map {
+		return fmt.Errorf("map ssz type can only be represented by map types, got %v", desc.Kind)
+	}
+
+	keyType := t.Key()
+	switch keyType.Kind() {
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+	default:
+		return fmt.Errorf("map ssz type can only be represented by maps with unsigned integer keys, got %v", keyType.Kind())
+	}
+
+	if desc.SszTypeFlags&SszTypeFlagHasLimit == 0 {
+		return fmt.Errorf("map ssz type requires a max size (ssz-max tag)")
+	}
+
+	if len(sizeHints) > 0 && !sizeHints[0].Dynamic {
+		// the number of entries can't be fixed, the first dimension must be "?" to size the values
+		return fmt.Errorf("map ssz type does not support a fixed size (ssz-size tag), use \"?\" for the map dimension")
+	}
+
+	childSizeHints := []SszSizeHint{}
+	if len(sizeHints) > 1 {
+		childSizeHints = sizeHints[1:]
+	}
+
+	childMaxSizeHints := []SszMaxSizeHint{}
+	if len(maxSizeHints) > 1 {
+		childMaxSizeHints = maxSizeHints[1:]
+	}
+
+	childTypeHints := []SszTypeHint{}
+	if len(typeHints) > 1 {
+		childTypeHints = typeHints[1:]
+	}
+
+	keyDesc, err := tc.getTypeDescriptor(keyType, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	valueDesc, err := tc.getTypeDescriptor(t.Elem(), childSizeHints, childMaxSizeHints, childTypeHints)
+	if err != nil {
+		return err
+	}
+
+	entryType := reflect.StructOf([]reflect.StructField{
+		{Name: "Key", Type: keyType},
+		{Name: "Value", Type: t.Elem()},
+	})
+	entryDesc := &TypeDescriptor{
+		Type:    entryType,
+		Kind:    reflect.Struct,
+		SszType: SszContainerType,
+		ContainerDesc: &ContainerDescriptor{
+			Fields: []FieldDescriptor{
+				{Name: "Key", Type: keyDesc},
+				{Name: "Value", Type: valueDesc},
+			},
+			DynFields: make([]DynFieldDescriptor, 0),
+		},
+	}
+	entryDesc.SszTypeFlags |= valueDesc.SszTypeFlags & (SszTypeFlagHasDynamicSize | SszTypeFlagHasDynamicMax | SszTypeFlagHasSizeExpr | SszTypeFlagHasMaxExpr)
+
+	if valueDesc.SszTypeFlags&SszTypeFlagIsDynamic != 0 {
+		entryDesc.SszTypeFlags |= SszTypeFlagIsDynamic
+		entryDesc.ContainerDesc.DynFields = append(entryDesc.ContainerDesc.DynFields, DynFieldDescriptor{
+			Field:  &entryDesc.ContainerDesc.Fields[1],
+			Offset: keyDesc.Size,
+			Index:  1,
+		})
+	} else {
+		entryDesc.Size = keyDesc.Size + valueDesc.Size
+	}
+
+	desc.Size = 0 // maps are always dynamic
+	desc.SszTypeFlags |= SszTypeFlagIsDynamic
+	desc.SszTypeFlags |= entryDesc.SszTypeFlags & (SszTypeFlagHasDynamicSize | SszTypeFlagHasDynamicMax | SszTypeFlagHasSizeExpr | SszTypeFlagHasMaxExpr)
+
+	desc.ElemDesc = &TypeDescriptor{
+		Type:          reflect.SliceOf(entryType),
+		Kind:          reflect.Slice,
+		Limit:         desc.Limit,
+		ElemDesc:      entryDesc,
+		MaxExpression: desc.MaxExpression,
+		SszType:       SszListType,
+		SszTypeFlags:  desc.SszTypeFlags,
+	}
+
+	return nil
+}
+
 // GetAllTypes returns a slice of all types currently cached in the TypeCache.
 //
 // This method is useful for cache inspection, debugging, and understanding which types
 // have been processed and cached during the application's lifetime. The returned slice
-// contains the reflect.Type values in no particular order.
+// contains the reflect.Type values in no particular order. Types that are cached with
+// multiple hint combinations (e.g. []byte with different ssz-max tags) are included once.
 //
 // The method acquires a read lock to ensure thread-safe access to the cache.
 //
@@ -842,6 +1203,15 @@ func (tc *TypeCache) GetAllTypes() []reflect.Type {
 		types = append(types, t)
 	}
 
+	hintedTypes := make(map[reflect.Type]bool)
+	for key := range tc.hintedDescriptors {
+		if _, exists := tc.descriptors[key.Type]; exists || hintedTypes[key.Type] {
+			continue
+		}
+		hintedTypes[key.Type] = true
+		types = append(types, key.Type)
+	}
+
 	return types
 }
 
@@ -868,6 +1238,11 @@ func (tc *TypeCache) RemoveType(t reflect.Type) {
 	defer tc.mutex.Unlock()
 
 	delete(tc.descriptors, t)
+	for key := range tc.hintedDescriptors {
+		if key.Type == t {
+			delete(tc.hintedDescriptors, key)
+		}
+	}
 }
 
 // RemoveAllTypes clears all cached type descriptors from the cache.
@@ -893,8 +1268,9 @@ func (tc *TypeCache) RemoveAllTypes() {
 	tc.mutex.Lock()
 	defer tc.mutex.Unlock()
 
-	// Create new map to clear all references
+	// Create new maps to clear all references
 	tc.descriptors = make(map[reflect.Type]*TypeDescriptor)
+	tc.hintedDescriptors = make(map[hintedTypeKey]*TypeDescriptor)
 }
 
 // extractGenericTypeParameter extracts the generic type parameter from a CompatibleUnion type.
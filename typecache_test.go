@@ -0,0 +1,162 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+type typeCacheTestStruct struct {
+	A []uint16 `ssz-max:"16"`
+	B []uint16 `ssz-max:"16"`
+	C []uint16 `ssz-max:"32"`
+	D []uint16 `ssz-size:"4" dynssz-size:"TEST_SIZE"`
+}
+
+func TestTypeCacheHintedDescriptors(t *testing.T) {
+	ds := NewDynSsz(nil)
+	cache := ds.GetTypeCache()
+
+	desc, err := cache.GetTypeDescriptor(reflect.TypeOf(typeCacheTestStruct{}), nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to get type descriptor: %v", err)
+	}
+
+	fields := desc.ContainerDesc.Fields
+	if fields[0].Type != fields[1].Type {
+		t.Errorf("expected fields with identical hints to share the cached descriptor")
+	}
+	if fields[0].Type == fields[2].Type {
+		t.Errorf("expected fields with different hints to use different descriptors")
+	}
+	if fields[2].Type.Limit != 32 {
+		t.Errorf("expected limit 32, got %v", fields[2].Type.Limit)
+	}
+
+	// repeated lookups return the cached descriptor
+	desc2, err := cache.GetTypeDescriptor(reflect.TypeOf(typeCacheTestStruct{}), nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to get type descriptor: %v", err)
+	}
+	if desc != desc2 {
+		t.Errorf("expected cached descriptor to be reused")
+	}
+
+	// hinted types are reported once
+	typeCount := 0
+	for _, cachedType := range cache.GetAllTypes() {
+		if cachedType == reflect.TypeOf([]uint16{}) {
+			typeCount++
+		}
+	}
+	if typeCount != 1 {
+		t.Errorf("expected []uint16 to be listed once, got %v", typeCount)
+	}
+
+	cache.RemoveAllTypes()
+	if len(cache.GetAllTypes()) != 0 {
+		t.Errorf("expected empty cache after RemoveAllTypes")
+	}
+}
+
+func TestTypeCacheSpecValues(t *testing.T) {
+	testCases := []struct {
+		specs        map[string]any
+		expectedSize uint32
+	}{
+		{nil, 8},
+		{map[string]any{"TEST_SIZE": uint64(6)}, 12},
+		{map[string]any{"TEST_SIZE": uint64(2)}, 4},
+	}
+
+	for _, tc := range testCases {
+		ds := NewDynSsz(tc.specs)
+
+		// build the descriptor twice to ensure the cached layout matches the spec values
+		for i := 0; i < 2; i++ {
+			desc, err := ds.GetTypeCache().GetTypeDescriptor(reflect.TypeOf(typeCacheTestStruct{}), nil, nil, nil)
+			if err != nil {
+				t.Fatalf("failed to get type descriptor: %v", err)
+			}
+
+			if size := desc.ContainerDesc.Fields[3].Type.Size; size != tc.expectedSize {
+				t.Errorf("specs %v: expected field size %v, got %v", tc.specs, tc.expectedSize, size)
+			}
+		}
+	}
+}
+
+type recursiveTestSelf struct {
+	Value    uint64
+	Children []*recursiveTestSelf `ssz-max:"4"`
+}
+
+type recursiveTestA struct {
+	B *recursiveTestB
+}
+
+type recursiveTestB struct {
+	As []recursiveTestA `ssz-max:"4"`
+}
+
+type recursiveTestSiblings struct {
+	First  typeCacheTestStruct
+	Second *typeCacheTestStruct
+	Nested struct {
+		Third typeCacheTestStruct
+	}
+}
+
+func TestTypeCacheRecursiveTypes(t *testing.T) {
+	testCases := []struct {
+		name        string
+		input       any
+		expectedErr string
+	}{
+		{
+			name:        "self_reference",
+			input:       &recursiveTestSelf{},
+			expectedErr: "recursive type detected: recursiveTestSelf -> recursiveTestSelf",
+		},
+		{
+			name:        "mutual_reference",
+			input:       recursiveTestA{},
+			expectedErr: "recursive type detected: recursiveTestA -> recursiveTestB -> recursiveTestA",
+		},
+		{
+			name:        "nested_mutual_reference",
+			input:       recursiveTestB{},
+			expectedErr: "recursive type detected: recursiveTestB -> recursiveTestA -> recursiveTestB",
+		},
+		{
+			name:  "sibling_reuse",
+			input: recursiveTestSiblings{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ds := NewDynSsz(nil)
+
+			_, err := ds.HashTreeRoot(tc.input)
+			if tc.expectedErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !contains(err.Error(), tc.expectedErr) {
+				t.Fatalf("expected error containing %q, got %v", tc.expectedErr, err)
+			}
+
+			// the failed build leaves the cache in a usable state
+			if _, err := ds.HashTreeRoot(recursiveTestSiblings{}); err != nil {
+				t.Errorf("unexpected error after failed build: %v", err)
+			}
+		})
+	}
+}
@@ -58,6 +58,48 @@ func (u *CompatibleUnion[T]) GetDescriptorType() reflect.Type {
 	return reflect.TypeOf(zero).Elem()
 }
 
+// Union represents an SSZ Union type that holds exactly one of several possible types.
+// Like CompatibleUnion, it uses a descriptor struct T whose field order defines the selector values
+// of the union options. Unlike CompatibleUnion, the selector is mixed into the hash tree root
+// (mix_in_selector) as defined by the SSZ union spec.
+//
+// The first option of the descriptor struct may be of type UnionNone, which makes selector 0 the
+// None option. A None union carries no data and is encoded as the single selector byte.
+//
+// Usage:
+//
+//	type MaybePayload = dynssz.Union[struct {
+//	    None    dynssz.UnionNone
+//	    Payload ExecutionPayload
+//	}]
+//
+//	empty := MaybePayload{Selector: 0}
+//	value := MaybePayload{Selector: 1, Data: ExecutionPayload{...}}
+type Union[T any] struct {
+	Selector uint8
+	Data     interface{}
+}
+
+// UnionNone is the marker type for the None option of a Union.
+// It may only be used as the first field of a Union descriptor struct.
+type UnionNone struct{}
+
+// NewUnion creates a new Union with the specified selector and data.
+// The selector corresponds to the field index in the descriptor struct T.
+func NewUnion[T any](selector uint8, data interface{}) *Union[T] {
+	return &Union[T]{
+		Selector: selector,
+		Data:     data,
+	}
+}
+
+// GetDescriptorType returns the reflect.Type of the descriptor struct T.
+// This allows external code to access the descriptor type information.
+func (u *Union[T]) GetDescriptorType() reflect.Type {
+	var zero *T
+	return reflect.TypeOf(zero).Elem()
+}
+
 // UnionVariantInfo contains type and annotation information for a union variant
 type UnionVariantInfo struct {
 	Type         reflect.Type
@@ -109,3 +151,26 @@ func ExtractUnionDescriptorInfo(descriptorType reflect.Type, dynssz *DynSsz) (ma
 
 	return variantInfo, nil
 }
+
+// getUnionVariantValue returns the value held by the Data field of a union for the selected variant.
+// The dynamic type of the data must match the variant type, a pointer to the variant type is dereferenced.
+func getUnionVariantValue(variantDesc *TypeDescriptor, selector uint8, dataField reflect.Value) (reflect.Value, error) {
+	if dataField.IsNil() {
+		return reflect.Value{}, fmt.Errorf("missing data for union variant %d", selector)
+	}
+
+	dataValue := dataField.Elem()
+	dataType := dataValue.Type()
+	if dataType == variantDesc.Type {
+		return dataValue, nil
+	}
+
+	if dataType.Kind() == reflect.Ptr && dataType.Elem() == variantDesc.Type {
+		if dataValue.IsNil() {
+			return reflect.Value{}, fmt.Errorf("missing data for union variant %d", selector)
+		}
+		return dataValue.Elem(), nil
+	}
+
+	return reflect.Value{}, fmt.Errorf("union variant %d: expected %v, got %v", selector, variantDesc.Type, dataType)
+}
@@ -6,7 +6,6 @@ package dynssz
 import (
 	"fmt"
 	"reflect"
-	"strings"
 
 	"github.com/pk910/dynamic-ssz/sszutils"
 )
@@ -55,8 +54,8 @@ func (d *DynSsz) unmarshalType(targetType *TypeDescriptor, targetValue reflect.V
 		useFastSsz = true
 	}
 
-	if d.Verbose {
-		fmt.Printf("%stype: %s\t kind: %v\t fastssz: %v (compat: %v/ dynamic: %v)\n", strings.Repeat(" ", idt), targetType.Type.Name(), targetType.Kind, useFastSsz, isFastsszUnmarshaler, hasDynamicSize)
+	if d.logEnabled() {
+		d.log(idt, "unmarshal type", "type", targetType.Type.Name(), "kind", targetType.Kind, "fastssz", useFastSsz, "compat", isFastsszUnmarshaler, "dynamicSize", hasDynamicSize)
 	}
 
 	if useFastSsz {
@@ -121,11 +120,31 @@ func (d *DynSsz) unmarshalType(targetType *TypeDescriptor, targetValue reflect.V
 			if err != nil {
 				return 0, err
 			}
+		case SszOptionalType:
+			consumedBytes, err = d.unmarshalOptional(targetType, targetValue, ssz, idt)
+			if err != nil {
+				return 0, err
+			}
+		case SszStableContainerType:
+			consumedBytes, err = d.unmarshalStableContainer(targetType, targetValue, ssz, idt)
+			if err != nil {
+				return 0, err
+			}
+		case SszMapType:
+			consumedBytes, err = d.unmarshalMap(targetType, targetValue, ssz, idt)
+			if err != nil {
+				return 0, err
+			}
 		case SszCompatibleUnionType:
 			consumedBytes, err = d.unmarshalCompatibleUnion(targetType, targetValue, ssz, idt)
 			if err != nil {
 				return 0, err
 			}
+		case SszUnionType:
+			consumedBytes, err = d.unmarshalUnion(targetType, targetValue, ssz, idt)
+			if err != nil {
+				return 0, err
+			}
 
 		// primitive types
 		case SszBoolType:
@@ -182,8 +201,8 @@ func (d *DynSsz) unmarshalType(targetType *TypeDescriptor, targetValue reflect.V
 // The function validates that the Data field is present and unmarshals the wrapped value using its type descriptor.
 
 func (d *DynSsz) unmarshalTypeWrapper(targetType *TypeDescriptor, targetValue reflect.Value, ssz []byte, idt int) (int, error) {
-	if d.Verbose {
-		fmt.Printf("%sunmarshalTypeWrapper: %s\n", strings.Repeat(" ", idt), targetType.Type.Name())
+	if d.logEnabled() {
+		d.log(idt, "unmarshalTypeWrapper", "type", targetType.Type.Name())
 	}
 
 	// Get the Data field from the TypeWrapper
@@ -247,7 +266,7 @@ func (d *DynSsz) unmarshalContainer(targetType *TypeDescriptor, targetValue refl
 			fieldValue := targetValue.Field(i)
 			consumedBytes, err := d.unmarshalType(field.Type, fieldValue, fieldSsz, idt+2)
 			if err != nil {
-				return 0, fmt.Errorf("failed decoding field %v: %v", field.Name, err)
+				return 0, wrapFieldError(err, field.Name)
 			}
 			if consumedBytes != fieldSize {
 				return 0, fmt.Errorf("container field did not consume expected ssz range (consumed: %v, expected: %v)", consumedBytes, fieldSize)
@@ -298,7 +317,7 @@ func (d *DynSsz) unmarshalContainer(targetType *TypeDescriptor, targetValue refl
 		fieldValue := targetValue.Field(int(field.Index))
 		consumedBytes, err := d.unmarshalType(fieldDescriptor.Type, fieldValue, fieldSsz, idt+2)
 		if err != nil {
-			return 0, fmt.Errorf("failed decoding field %v: %v", fieldDescriptor.Name, err)
+			return 0, wrapFieldError(err, fieldDescriptor.Name)
 		}
 		if consumedBytes != endOffset-startOffset {
 			return 0, fmt.Errorf("struct field did not consume expected ssz range (consumed: %v, expected: %v)", consumedBytes, endOffset-startOffset)
@@ -337,6 +356,12 @@ func (d *DynSsz) unmarshalVector(targetType *TypeDescriptor, targetValue reflect
 	fieldType := targetType.ElemDesc
 	arrLen := int(targetType.Len)
 
+	if targetType.SszType == SszBitvectorType && len(ssz) >= arrLen {
+		if err := sszutils.ValidateBitvectorPadding(ssz[:arrLen], uint64(targetType.BitSize)); err != nil {
+			return 0, err
+		}
+	}
+
 	var newValue reflect.Value
 	switch targetType.Kind {
 	case reflect.Slice:
@@ -377,7 +402,7 @@ func (d *DynSsz) unmarshalVector(targetType *TypeDescriptor, targetValue reflect
 
 			consumed, err := d.unmarshalType(fieldType, itemVal, itemSsz, idt+2)
 			if err != nil {
-				return 0, err
+				return 0, wrapIndexError(err, i)
 			}
 			if consumed != itemSize {
 				return 0, fmt.Errorf("unmarshalling vector item did not consume expected ssz range (consumed: %v, expected: %v)", consumed, itemSize)
@@ -486,7 +511,7 @@ func (d *DynSsz) unmarshalDynamicVector(targetType *TypeDescriptor, targetValue
 
 		consumed, err := d.unmarshalType(fieldType, itemVal, itemSsz, idt+2)
 		if err != nil {
-			return 0, err
+			return 0, wrapIndexError(err, i)
 		}
 		if consumed != itemSize {
 			return 0, fmt.Errorf("dynamic vector item did not consume expected ssz range (consumed: %v, expected: %v)", consumed, itemSize)
@@ -581,7 +606,7 @@ func (d *DynSsz) unmarshalList(targetType *TypeDescriptor, targetValue reflect.V
 
 				consumed, err := d.unmarshalType(fieldType, itemVal, itemSsz, idt+2)
 				if err != nil {
-					return 0, err
+					return 0, wrapIndexError(err, i)
 				}
 				if consumed != itemSize {
 					return 0, fmt.Errorf("list item did not consume expected ssz range (consumed: %v, expected: %v)", consumed, itemSize)
@@ -691,7 +716,7 @@ func (d *DynSsz) unmarshalDynamicList(targetType *TypeDescriptor, targetValue re
 
 			consumed, err := d.unmarshalType(fieldType, itemVal, itemSsz, idt+2)
 			if err != nil {
-				return 0, err
+				return 0, wrapIndexError(err, i)
 			}
 			if consumed != itemSize {
 				return 0, fmt.Errorf("dynamic list item did not consume expected ssz range (consumed: %v, expected: %v)", consumed, itemSize)
@@ -742,7 +767,7 @@ func (d *DynSsz) unmarshalCompatibleUnion(targetType *TypeDescriptor, targetValu
 	// Unmarshal the data
 	consumed, err := d.unmarshalType(variantDesc, variantValue, ssz[1:], idt+2)
 	if err != nil {
-		return 0, fmt.Errorf("failed to unmarshal union variant %d: %w", variant, err)
+		return 0, wrapFieldError(err, "Data")
 	}
 
 	// We know CompatibleUnion has exactly 2 fields: Variant (uint8) and Data (interface{})
@@ -752,3 +777,228 @@ func (d *DynSsz) unmarshalCompatibleUnion(targetType *TypeDescriptor, targetValu
 
 	return consumed + 1, nil // +1 for the selector byte
 }
+
+// unmarshalUnion decodes SSZ-encoded data into a Union.
+//
+// According to the spec:
+// - The encoding is: selector.to_bytes(1, "little") + serialize(value.value)
+// - The None option (selector 0) is encoded as the selector byte only
+// - Selectors that do not match a union option are invalid
+//
+// Parameters:
+//   - targetType: The TypeDescriptor containing union metadata and variant descriptors
+//   - targetValue: The reflect.Value of the Union to populate
+//   - ssz: The SSZ-encoded data to decode
+//   - idt: Indentation level for verbose logging
+//
+// Returns:
+//   - int: Total bytes consumed
+//   - error: An error if decoding fails
+func (d *DynSsz) unmarshalUnion(targetType *TypeDescriptor, targetValue reflect.Value, ssz []byte, idt int) (int, error) {
+	if len(ssz) < 1 {
+		return 0, fmt.Errorf("Union requires at least 1 byte for selector")
+	}
+
+	selector := ssz[0]
+
+	variantDesc, ok := targetType.UnionVariants[selector]
+	if !ok {
+		return 0, fmt.Errorf("%w: selector %d out of range", sszutils.ErrInvalidUnionVariant, selector)
+	}
+
+	// Union has exactly 2 fields: Selector (uint8) and Data (interface{})
+	targetValue.Field(0).SetUint(uint64(selector))
+
+	if variantDesc == nil {
+		// None option, no data
+		if len(ssz) > 1 {
+			return 0, fmt.Errorf("union None option must not carry data, got %d bytes", len(ssz)-1)
+		}
+		targetValue.Field(1).Set(reflect.Zero(targetValue.Field(1).Type()))
+		return 1, nil
+	}
+
+	variantValue := reflect.New(variantDesc.Type).Elem()
+
+	consumed, err := d.unmarshalType(variantDesc, variantValue, ssz[1:], idt+2)
+	if err != nil {
+		return 0, wrapFieldError(err, "Data")
+	}
+
+	targetValue.Field(1).Set(variantValue)
+
+	return consumed + 1, nil // +1 for the selector byte
+}
+
+// unmarshalOptional decodes SSZ-encoded data into an Optional pointer.
+//
+// According to EIP-6475:
+// - An empty byte sequence decodes to an absent value (nil pointer)
+// - Otherwise the data must start with the 0x01 selector, followed by the serialized value
+//
+// Parameters:
+//   - targetType: The TypeDescriptor containing the optional's value descriptor
+//   - targetValue: The reflect.Value of the optional pointer to populate
+//   - ssz: The SSZ-encoded data to decode
+//   - idt: Indentation level for verbose logging
+//
+// Returns:
+//   - int: Total bytes consumed
+//   - error: An error if decoding fails
+func (d *DynSsz) unmarshalOptional(targetType *TypeDescriptor, targetValue reflect.Value, ssz []byte, idt int) (int, error) {
+	if len(ssz) == 0 {
+		targetValue.Set(reflect.Zero(targetType.Type))
+		return 0, nil
+	}
+
+	if ssz[0] != 1 {
+		return 0, fmt.Errorf("invalid optional selector: %d", ssz[0])
+	}
+
+	newValue := reflect.New(targetType.Type.Elem())
+
+	consumed, err := d.unmarshalType(targetType.ElemDesc, newValue.Elem(), ssz[1:], idt+2)
+	if err != nil {
+		return 0, err
+	}
+
+	targetValue.Set(newValue)
+
+	return consumed + 1, nil // +1 for the selector byte
+}
+
+// unmarshalStableContainer decodes SSZ-encoded data into a stable container.
+//
+// According to EIP-7495:
+// - The encoding is: serialize(active_fields) + serialize(active field values as container)
+// - Fields with an unset active bit are absent and decoded as nil pointers
+// - Active bits beyond the defined fields are invalid
+//
+// Parameters:
+//   - targetType: The TypeDescriptor containing stable container metadata
+//   - targetValue: The reflect.Value of the stable container to populate
+//   - ssz: The SSZ-encoded data to decode
+//   - idt: Indentation level for verbose logging
+//
+// Returns:
+//   - int: Total bytes consumed
+//   - error: An error if decoding fails
+func (d *DynSsz) unmarshalStableContainer(targetType *TypeDescriptor, targetValue reflect.Value, ssz []byte, idt int) (int, error) {
+	fields := targetType.ContainerDesc.Fields
+	bitvectorLen := int(targetType.Len+7) / 8
+	if len(ssz) < bitvectorLen {
+		return 0, sszutils.ErrUnexpectedEOF
+	}
+
+	activeFields := ssz[:bitvectorLen]
+	if err := sszutils.ValidateBitvectorPadding(activeFields, uint64(targetType.Len)); err != nil {
+		return 0, err
+	}
+	for i := len(fields); i < int(targetType.Len); i++ {
+		if activeFields[i/8]&(1<<(i%8)) != 0 {
+			return 0, fmt.Errorf("stable container has active bit %d beyond its %d fields", i, len(fields))
+		}
+	}
+
+	isActive := func(i int) bool {
+		return activeFields[i/8]&(1<<(i%8)) != 0
+	}
+
+	ssz = ssz[bitvectorLen:]
+	sszSize := len(ssz)
+	offset := 0
+	dynamicFields := make([]int, 0, len(targetType.ContainerDesc.DynFields))
+	dynamicOffsets := make([]int, 0, len(targetType.ContainerDesc.DynFields))
+
+	for i, field := range fields {
+		fieldValue := targetValue.Field(i)
+		if !isActive(i) {
+			fieldValue.Set(reflect.Zero(field.Type.Type))
+			continue
+		}
+
+		fieldSize := int(field.Type.Size)
+		if fieldSize > 0 {
+			if offset+fieldSize > sszSize {
+				return 0, fmt.Errorf("unexpected end of SSZ. field %v expects %v bytes, got %v", field.Name, fieldSize, sszSize-offset)
+			}
+
+			consumedBytes, err := d.unmarshalType(field.Type, fieldValue, ssz[offset:offset+fieldSize], idt+2)
+			if err != nil {
+				return 0, wrapFieldError(err, field.Name)
+			}
+			if consumedBytes != fieldSize {
+				return 0, fmt.Errorf("container field did not consume expected ssz range (consumed: %v, expected: %v)", consumedBytes, fieldSize)
+			}
+		} else {
+			fieldSize = 4
+			if offset+fieldSize > sszSize {
+				return 0, fmt.Errorf("unexpected end of SSZ. dynamic field %v expects %v bytes (offset), got %v", field.Name, fieldSize, sszSize-offset)
+			}
+
+			dynamicFields = append(dynamicFields, i)
+			dynamicOffsets = append(dynamicOffsets, int(sszutils.ReadOffset(ssz[offset:offset+fieldSize])))
+		}
+		offset += fieldSize
+	}
+
+	for i, fieldIndex := range dynamicFields {
+		startOffset := dynamicOffsets[i]
+		endOffset := sszSize
+		if i < len(dynamicFields)-1 {
+			endOffset = dynamicOffsets[i+1]
+		}
+
+		// check offset integrity (not before previous field offset & not after range end)
+		if startOffset != offset || endOffset > sszSize || endOffset < startOffset {
+			return 0, sszutils.ErrOffset
+		}
+
+		field := fields[fieldIndex]
+		consumedBytes, err := d.unmarshalType(field.Type, targetValue.Field(fieldIndex), ssz[startOffset:endOffset], idt+2)
+		if err != nil {
+			return 0, wrapFieldError(err, field.Name)
+		}
+		if consumedBytes != endOffset-startOffset {
+			return 0, fmt.Errorf("struct field did not consume expected ssz range (consumed: %v, expected: %v)", consumedBytes, endOffset-startOffset)
+		}
+
+		offset += consumedBytes
+	}
+
+	return bitvectorLen + offset, nil
+}
+
+// unmarshalMap decodes SSZ-encoded data into a map.
+//
+// The data is decoded as a list of {Key, Value} entry containers. To keep the encoding canonical,
+// the entry keys must be sorted in ascending order without duplicates.
+//
+// Parameters:
+//   - targetType: The TypeDescriptor containing the map's entry list descriptor
+//   - targetValue: The reflect.Value of the map to populate
+//   - ssz: The SSZ-encoded data to decode
+//   - idt: Indentation level for verbose logging
+//
+// Returns:
+//   - int: Total bytes consumed
+//   - error: An error if decoding fails or the keys are not strictly increasing
+func (d *DynSsz) unmarshalMap(targetType *TypeDescriptor, targetValue reflect.Value, ssz []byte, idt int) (int, error) {
+	entries := reflect.New(targetType.ElemDesc.Type).Elem()
+
+	consumed, err := d.unmarshalType(targetType.ElemDesc, entries, ssz, idt+2)
+	if err != nil {
+		return 0, err
+	}
+
+	if uint64(entries.Len()) > targetType.Limit {
+		return 0, sszutils.ErrListTooBig
+	}
+
+	err = setMapEntries(targetValue, entries)
+	if err != nil {
+		return 0, err
+	}
+
+	return consumed, nil
+}
@@ -156,6 +156,19 @@ var unmarshalTestMatrix = []struct {
 		}{[4]byte{0xff, 0x0f, 0x00, 0xf0}},
 		fromHex("0xff0f00f0"),
 	},
+	{
+		struct {
+			Flags [2]byte `ssz-bitsize:"12"`
+		}{[2]byte{0xff, 0x0f}},
+		fromHex("0xff0f"),
+	},
+	{
+		struct {
+			Flags []byte `ssz-type:"bitvector" ssz-bitsize:"12"`
+			Value uint16
+		}{[]byte{0x01, 0x08}, 0x1337},
+		fromHex("0x01083713"),
+	},
 
 	// explicit basic type annotations
 	{
@@ -256,6 +269,180 @@ var unmarshalTestMatrix = []struct {
 		fromHex("0x37130800000042420078563412"),
 	},
 
+	// Union tests (selector 0 is the None option)
+	{
+		struct {
+			A uint16
+			B Union[struct {
+				None  UnionNone
+				Value uint32
+				List  []uint8 `ssz-max:"8"`
+			}]
+			C uint16
+		}{0x1337, Union[struct {
+			None  UnionNone
+			Value uint32
+			List  []uint8 `ssz-max:"8"`
+		}]{Selector: 0}, 0x4242},
+		fromHex("0x371308000000424200"),
+	},
+	{
+		struct {
+			A uint16
+			B Union[struct {
+				None  UnionNone
+				Value uint32
+				List  []uint8 `ssz-max:"8"`
+			}]
+			C uint16
+		}{0x1337, Union[struct {
+			None  UnionNone
+			Value uint32
+			List  []uint8 `ssz-max:"8"`
+		}]{Selector: 1, Data: uint32(0x12345678)}, 0x4242},
+		fromHex("0x37130800000042420178563412"),
+	},
+	{
+		struct {
+			A uint16
+			B Union[struct {
+				None  UnionNone
+				Value uint32
+				List  []uint8 `ssz-max:"8"`
+			}]
+			C uint16
+		}{0x1337, Union[struct {
+			None  UnionNone
+			Value uint32
+			List  []uint8 `ssz-max:"8"`
+		}]{Selector: 2, Data: []uint8{1, 2, 3}}, 0x4242},
+		fromHex("0x371308000000424202010203"),
+	},
+
+	// Optional tests (EIP-6475)
+	{
+		struct {
+			A uint16
+			B *uint64 `ssz-type:"optional"`
+			C uint16
+		}{0x1337, nil, 0x4242},
+		fromHex("0x3713080000004242"),
+	},
+	{
+		struct {
+			A uint16
+			B *uint64 `ssz-type:"optional"`
+			C uint16
+		}{0x1337, func() *uint64 { v := uint64(0x1122); return &v }(), 0x4242},
+		fromHex("0x3713080000004242012211000000000000"),
+	},
+	{
+		struct {
+			A uint16
+			B *[]uint8 `ssz-type:"optional" ssz-max:"8"`
+			C uint16
+		}{0x1337, &[]uint8{1, 2, 3}, 0x4242},
+		fromHex("0x371308000000424201010203"),
+	},
+
+	// StableContainer tests (EIP-7495)
+	{
+		struct {
+			A uint16
+			S struct {
+				X *uint16
+				Y *[]uint8 `ssz-max:"8"`
+				Z *uint32
+			} `ssz-type:"stable-container" ssz-size:"4"`
+			C uint16
+		}{A: 0x1337, C: 0x4242},
+		fromHex("0x371308000000424200"),
+	},
+	{
+		struct {
+			A uint16
+			S struct {
+				X *uint16
+				Y *[]uint8 `ssz-max:"8"`
+				Z *uint32
+			} `ssz-type:"stable-container" ssz-size:"4"`
+			C uint16
+		}{A: 0x1337, C: 0x4242, S: struct {
+			X *uint16
+			Y *[]uint8 `ssz-max:"8"`
+			Z *uint32
+		}{X: func() *uint16 { v := uint16(0x1122); return &v }(), Y: &[]uint8{1, 2, 3}}},
+		fromHex("0x371308000000424203221106000000010203"),
+	},
+
+	// multi-dimensional lists and vectors (one size/max hint per dimension)
+	{
+		struct {
+			A [][][]byte `ssz-max:"4,8,16"`
+		}{[][][]byte{{{1, 2}, {3}}, nil, {{4, 5, 6}}}},
+		fromHex("0x040000000c0000001700000017000000080000000a00000001020304000000040506"),
+	},
+	{
+		struct {
+			A [][][]uint16 `ssz-size:"?,2,?" ssz-max:"4,?,8"`
+		}{[][][]uint16{{{1}, {2, 3}}, {{}, {4}}}},
+		fromHex("0x040000000800000016000000080000000a00000001000200030008000000080000000400"),
+	},
+	{
+		struct {
+			A [2][][]byte `ssz-max:"?,4,16"`
+		}{[2][][]byte{{{1}, {2, 3}}, nil}},
+		fromHex("0x0400000008000000130000000800000009000000010203"),
+	},
+	{
+		struct {
+			A []struct {
+				X [][]byte `ssz-max:"2,4"`
+				Y uint8
+			} `ssz-max:"3"`
+		}{[]struct {
+			X [][]byte `ssz-max:"2,4"`
+			Y uint8
+		}{{X: [][]byte{{1, 2}, {3}}, Y: 7}, {X: nil, Y: 8}}},
+		fromHex("0x0400000008000000180000000500000007080000000a0000000102030500000008"),
+	},
+	{
+		struct {
+			A [2][2][]uint8 `ssz-max:"?,?,4"`
+			B uint16
+		}{[2][2][]uint8{{{1}, {}}, {{2, 3}, {4}}}, 0x1337},
+		fromHex("0x0600000037130800000011000000080000000900000001080000000a000000020304"),
+	},
+	{
+		struct {
+			A [][][3]uint16 `ssz-max:"4,8"`
+		}{[][][3]uint16{{{1, 2, 3}, {4, 5, 6}}, {}, {{7, 8, 9}}}},
+		fromHex("0x040000000c0000001800000018000000010002000300040005000600070008000900"),
+	},
+
+	// map types (sorted list of key/value entries)
+	{
+		struct {
+			A uint16
+			M map[uint64]uint16 `ssz-max:"4"`
+			C uint16
+		}{0x1337, map[uint64]uint16{5: 0x3344, 1: 0x1122, 3: 0x5566}, 0x4242},
+		fromHex("0x3713080000004242010000000000000022110300000000000000665505000000000000004433"),
+	},
+	{
+		struct {
+			A uint16
+			M map[uint32][]uint8 `ssz-max:"4,8"`
+		}{0x1337, map[uint32][]uint8{2: {1, 2}, 1: {}}},
+		fromHex("0x3713060000000800000010000000010000000800000002000000080000000102"),
+	},
+	{
+		struct {
+			M map[uint64]uint16 `ssz-max:"4"`
+		}{map[uint64]uint16{}},
+		fromHex("0x04000000"),
+	},
+
 	// string types
 	{
 		struct {
@@ -655,7 +842,7 @@ func TestUnmarshalErrors(t *testing.T) {
 				} `ssz-max:"10"`
 			}),
 			data:        fromHex("0x010000000800000008000000ff000000"),
-			expectedErr: "failed decoding field B: incorrect offset",
+			expectedErr: "B: incorrect offset",
 		},
 		{
 			name: "map_type",
@@ -663,7 +850,7 @@ func TestUnmarshalErrors(t *testing.T) {
 				Data map[string]int
 			}),
 			data:        fromHex("0x04000000"),
-			expectedErr: "maps are not supported in SSZ",
+			expectedErr: "map ssz type can only be represented by maps with unsigned integer keys",
 		},
 		{
 			name: "interface_type",
@@ -689,6 +876,89 @@ func TestUnmarshalErrors(t *testing.T) {
 			data:        fromHex("0x00000000"),
 			expectedErr: "functions are not supported in SSZ",
 		},
+		{
+			name: "bitvector_padding_bits",
+			target: new(struct {
+				Flags [2]byte `ssz-bitsize:"12"`
+			}),
+			data:        fromHex("0xff1f"),
+			expectedErr: "bitvector padding bits are not zero",
+		},
+		{
+			name: "optional_invalid_selector",
+			target: new(struct {
+				A uint16
+				B *uint64 `ssz-type:"optional"`
+			}),
+			data:        fromHex("0x371306000000022211000000000000"),
+			expectedErr: "invalid optional selector: 2",
+		},
+		{
+			name: "stable_container_unknown_active_field",
+			target: new(struct {
+				S struct {
+					A *uint16
+				} `ssz-type:"stable-container" ssz-size:"4"`
+			}),
+			data:        fromHex("0x04000000022211"),
+			expectedErr: "stable container has active bit 1 beyond its 1 fields",
+		},
+		{
+			name: "stable_container_padding_bits",
+			target: new(struct {
+				S struct {
+					A *uint16
+				} `ssz-type:"stable-container" ssz-size:"4"`
+			}),
+			data:        fromHex("0x04000000112211"),
+			expectedErr: "bitvector padding bits are not zero",
+		},
+		{
+			name: "map_duplicate_key",
+			target: new(struct {
+				M map[uint16]uint16 `ssz-max:"4"`
+			}),
+			data:        fromHex("0x040000000100110001002200"),
+			expectedErr: "M[1]: duplicate map key 1",
+		},
+		{
+			name: "map_unsorted_keys",
+			target: new(struct {
+				M map[uint16]uint16 `ssz-max:"4"`
+			}),
+			data:        fromHex("0x040000000200110001002200"),
+			expectedErr: "M[1]: map keys are not sorted in ascending order (key 1 after 2)",
+		},
+		{
+			name: "map_too_many_entries",
+			target: new(struct {
+				M map[uint16]uint16 `ssz-max:"1"`
+			}),
+			data:        fromHex("0x040000000100110002002200"),
+			expectedErr: "list length is higher than max value",
+		},
+		{
+			name: "union_selector_out_of_range",
+			target: new(struct {
+				U Union[struct {
+					None  UnionNone
+					Value uint32
+				}]
+			}),
+			data:        fromHex("0x040000000278563412"),
+			expectedErr: "U: invalid union variant: selector 2 out of range",
+		},
+		{
+			name: "union_none_with_data",
+			target: new(struct {
+				U Union[struct {
+					None  UnionNone
+					Value uint32
+				}]
+			}),
+			data:        fromHex("0x040000000078563412"),
+			expectedErr: "union None option must not carry data, got 4 bytes",
+		},
 		{
 			name: "corrupted_dynamic_offsets",
 			target: new(struct {